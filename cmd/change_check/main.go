@@ -25,32 +25,95 @@ type Change struct {
 type Report struct {
 	OK             bool     `json:"ok"`
 	CheckedAt      string   `json:"checked_at"`
-	DiffFile       string   `json:"diff_file"`
+	DiffFile       string   `json:"diff_file,omitempty"`
+	Baseline       string   `json:"baseline,omitempty"` // compared trees, without -diff
+	Current        string   `json:"current,omitempty"`
 	ConfigFile     string   `json:"config_file"`
+	Stream         string   `json:"stream"`
 	AllowList      []string `json:"allow_list"`
 	ChangedPaths   []string `json:"changed_paths"`
 	Unexpected     []string `json:"unexpected"`
 	UnexpectedBySt []Change `json:"unexpected_by_status,omitempty"`
 }
 
+const usage = `usage: change_check [flags]
+
+Checks that every changed file matches the allow list of the stream.
+Changes are read from a git diff --name-status file (-diff), or computed
+by comparing two trees by content:
+
+  change_check -baseline baseline -current current   two directories
+  change_check -base upstream/main                    a ref and the working tree
+  change_check -base v1 -head HEAD                    two refs of -repo
+
+-baseline/-base and -current/-head can be mixed; without -current and
+-head the working tree of -repo is compared (tracked and untracked files,
+minus ignored ones). Files moved without edits are reported as renames.
+
+exit codes: 0 all changes allowed, 1 unexpected changes, 2 usage or io error
+
+flags:
+`
+
 func main() {
 	cfgPath := flag.String("config", "./.etc/config.json", "config file")
 	diffPath := flag.String("diff", "changed_files.raw", "path to diff file (prefer changed_files.raw)")
+	baselineDir := flag.String("baseline", "", "baseline directory to compare with")
+	currentDir := flag.String("current", "", "current directory to compare")
+	baseRef := flag.String("base", "", "baseline git ref in -repo to compare with")
+	headRef := flag.String("head", "", "current git ref in -repo (default: the working tree)")
+	repo := flag.String("repo", ".", "git repository for -base and -head")
+	stream := flag.String("stream", "", "stream profile (default: $INDUSTRY_STREAM or \"stream\" from config)")
+	overridePath := flag.String("override", "", "optional local override config (default: config.local.json next to -config)")
 	outPath := flag.String("out", "change-policy-result.json", "output json file")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 
-	cfg, err := config.Load(*cfgPath)
+	explicit := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+	native := *baselineDir != "" || *baseRef != "" || *currentDir != "" || *headRef != ""
+	var base, cur source
+	if native {
+		switch {
+		case explicit["diff"]:
+			fmt.Fprintln(os.Stderr, "ERROR: -diff cannot be combined with -baseline, -current, -base or -head")
+			os.Exit(2)
+		case *baselineDir != "" && *baseRef != "", *currentDir != "" && *headRef != "":
+			fmt.Fprintln(os.Stderr, "ERROR: give a side either as a directory or as a ref, not both")
+			os.Exit(2)
+		case *baselineDir == "" && *baseRef == "":
+			fmt.Fprintln(os.Stderr, "ERROR: -baseline or -base is required to compare trees")
+			os.Exit(2)
+		}
+		base = source{dir: *baselineDir, ref: *baseRef, repo: *repo}
+		cur = source{dir: *currentDir, ref: *headRef, repo: *repo}
+	}
+
+	resolved, err := config.LoadLayered(config.Options{Path: *cfgPath, OverridePath: *overridePath, Environ: os.Environ()})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR:", err)
+		os.Exit(2)
+	}
+	profile, err := resolved.Config.Profile(*stream)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "ERROR:", err)
 		os.Exit(2)
 	}
-	matchers, err := compileAllowList(cfg.Diff.AllowList)
+	matchers, err := compileAllowList(profile.AllowList)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "config read error:", err)
 		os.Exit(2)
 	}
 
-	changes, err := readChanges(*diffPath)
+	var changes []Change
+	if native {
+		changes, err = diffTrees(base, cur)
+	} else {
+		changes, err = readChanges(*diffPath)
+	}
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "diff read error:", err)
 		os.Exit(2)
@@ -61,8 +124,8 @@ func main() {
 	unexpectedSet := map[string]struct{}{}
 	var unexpectedBySt []Change
 
+	// пути из -diff нормализованы в readChanges, пути из деревьев берём как есть
 	addChanged := func(p string) {
-		p = normalizePath(p)
 		if p == "" {
 			return
 		}
@@ -96,19 +159,8 @@ func main() {
 
 	// детализируем unexpectedBySt (чтобы было понятно, что именно случилось)
 	for _, ch := range changes {
-		paths := []string{}
-		if ch.Path != "" {
-			paths = append(paths, normalizePath(ch.Path))
-		}
-		if ch.From != "" {
-			paths = append(paths, normalizePath(ch.From))
-		}
-		if ch.To != "" {
-			paths = append(paths, normalizePath(ch.To))
-		}
-
 		bad := false
-		for _, p := range paths {
+		for _, p := range []string{ch.Path, ch.From, ch.To} {
 			if p == "" {
 				continue
 			}
@@ -129,12 +181,17 @@ func main() {
 		CheckedAt:      time.Now().UTC().Format(time.RFC3339),
 		DiffFile:       *diffPath,
 		ConfigFile:     *cfgPath,
-		AllowList:      cfg.Diff.AllowList,
+		Stream:         profile.Name,
+		AllowList:      profile.AllowList,
 		ChangedPaths:   changedPaths,
 		Unexpected:     unexpected,
 		UnexpectedBySt: unexpectedBySt,
 	}
 
+	if native {
+		rep.DiffFile, rep.Baseline, rep.Current = "", base.String(), cur.String()
+	}
+
 	if *outPath != "" {
 		if err := writeJSON(*outPath, rep); err != nil {
 			fmt.Fprintln(os.Stderr, "report write error:", err)
@@ -189,7 +246,7 @@ func compileAllowList(patterns []string) ([]matcher, error) {
 		if pat == "" {
 			continue
 		}
-		re, err := globToRegex(pat)
+		re, err := config.CompileGlob(pat)
 		if err != nil {
 			return nil, fmt.Errorf("pattern %q: %w", pat, err)
 		}
@@ -210,46 +267,10 @@ func isAllowed(p string, matchers []matcher) bool {
 	return false
 }
 
-func globToRegex(pat string) (*regexp.Regexp, error) {
-	pat = normalizePath(pat)
-	if strings.HasSuffix(pat, "/") {
-		// "dir/" => всё внутри
-		pat = pat + "**"
-	}
-
-	var b strings.Builder
-	b.WriteString("^")
-	for i := 0; i < len(pat); i++ {
-		ch := pat[i]
-
-		if ch == '*' {
-			// ** => match across slashes
-			if i+1 < len(pat) && pat[i+1] == '*' {
-				b.WriteString(".*")
-				i++
-				continue
-			}
-			// * => match within a path segment
-			b.WriteString(`[^/]*`)
-			continue
-		}
-
-		if ch == '?' {
-			b.WriteString(`[^/]`)
-			continue
-		}
-
-		// escape regexp metachars
-		if strings.ContainsRune(`.+()|[]{}^$\/`, rune(ch)) {
-			b.WriteByte('\\')
-		}
-		b.WriteByte(ch)
-	}
-	b.WriteString("$")
-
-	return regexp.Compile(b.String())
-}
-
+// normalizePath cleans a path from a -diff file, which may carry a/ b/
+// prefixes or the names of the compared directories. Tree comparison
+// produces exact repo paths and must not go through it: a real file
+// b/x.go would become x.go.
 func normalizePath(p string) string {
 	p = strings.TrimSpace(p)
 	if p == "" {
@@ -306,6 +327,7 @@ func readChanges(diffFile string) ([]Change, error) {
 			}
 			continue
 		}
+		ch.Path, ch.From, ch.To = normalizePath(ch.Path), normalizePath(ch.From), normalizePath(ch.To)
 		out = append(out, ch)
 	}
 	if err := sc.Err(); err != nil {
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// snapshot maps slash-separated paths, relative to the tree root, to the git
+// blob id of their content. Blob ids of files on disk are computed the way
+// git computes them, so a directory compares against a git ref directly.
+type snapshot map[string]string
+
+// emptyBlob is the id of an empty file; empty files are not paired as
+// renames, since any two of them look alike.
+var emptyBlob = blobID(nil)
+
+func blobID(data []byte) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "blob %d\x00", len(data))
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// fileBlob hashes a regular file or, like git, the target of a symlink.
+// ok is false for anything else (directories, sockets, a file gone since
+// it was listed).
+func fileBlob(p string) (id string, ok bool, err error) {
+	fi, err := os.Lstat(p)
+	if os.IsNotExist(err) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	var data []byte
+	switch {
+	case fi.Mode().IsRegular():
+		data, err = os.ReadFile(p)
+	case fi.Mode()&os.ModeSymlink != 0:
+		var target string
+		target, err = os.Readlink(p)
+		data = []byte(filepath.ToSlash(target))
+	default:
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return blobID(data), true, nil
+}
+
+// dirSnapshot hashes every file under root. .git is skipped, so a plain
+// checkout can be compared without removing it first.
+func dirSnapshot(root string) (snapshot, error) {
+	fi, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", root)
+	}
+	snap := snapshot{}
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Name() == ".git" && p != root {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil // .git-файл у worktree и сабмодулей
+		}
+		if d.IsDir() {
+			return nil
+		}
+		id, ok, err := fileBlob(p)
+		if err != nil || !ok {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		snap[filepath.ToSlash(rel)] = id
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return snap, nil
+}
+
+// refSnapshot lists the tree of a commit-ish without checking it out.
+func refSnapshot(repo, ref string) (snapshot, error) {
+	out, err := git(repo, "ls-tree", "-r", "-z", "--full-tree", ref)
+	if err != nil {
+		return nil, err
+	}
+	snap := snapshot{}
+	for _, rec := range bytes.Split(out, []byte{0}) {
+		if len(rec) == 0 {
+			continue
+		}
+		// <mode> SP <type> SP <object> TAB <path>
+		meta, p, ok := strings.Cut(string(rec), "\t")
+		fields := strings.Fields(meta)
+		if !ok || len(fields) != 3 {
+			return nil, fmt.Errorf("git ls-tree %s: unexpected line %q", ref, rec)
+		}
+		if fields[1] != "blob" {
+			continue // сабмодули (commit) не сравниваем
+		}
+		snap[p] = fields[2]
+	}
+	return snap, nil
+}
+
+// worktreeSnapshot hashes the working tree of repo: tracked files and
+// untracked ones that are not ignored, i.e. what a commit of everything
+// would contain.
+func worktreeSnapshot(repo string) (snapshot, error) {
+	top, err := git(repo, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	root := strings.TrimSpace(string(top))
+	out, err := git(root, "ls-files", "-z", "--cached", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+	snap := snapshot{}
+	for _, p := range strings.Split(string(out), "\x00") {
+		if p == "" {
+			continue
+		}
+		// удалённый с диска, но ещё не закоммиченный файл просто не попадёт в снимок
+		id, ok, err := fileBlob(filepath.Join(root, filepath.FromSlash(p)))
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			snap[p] = id
+		}
+	}
+	return snap, nil
+}
+
+func git(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return nil, fmt.Errorf("git %s: %s", strings.Join(args, " "), msg)
+	}
+	return out, nil
+}
+
+// compare lists what changed from base to cur. A file deleted in one place
+// and added with the same content in another is a rename (R100, as git
+// names exact renames); an edited and moved file stays a delete and an add,
+// which touches the same paths for the allow list.
+func compare(base, cur snapshot) []Change {
+	var out []Change
+	deleted := map[string][]string{} // blob id -> paths
+	var added []string
+	for p, id := range base {
+		if _, ok := cur[p]; !ok {
+			deleted[id] = append(deleted[id], p)
+		}
+	}
+	for p, id := range cur {
+		old, ok := base[p]
+		switch {
+		case !ok:
+			added = append(added, p)
+		case old != id:
+			out = append(out, Change{Status: "M", Path: p})
+		}
+	}
+	for _, ps := range deleted {
+		sort.Strings(ps)
+	}
+	sort.Strings(added)
+
+	for _, p := range added {
+		id := cur[p]
+		cands := deleted[id]
+		if id == emptyBlob || len(cands) == 0 {
+			out = append(out, Change{Status: "A", Path: p})
+			continue
+		}
+		// из одинаковых кандидатов предпочитаем файл с тем же именем
+		i := 0
+		for j, c := range cands {
+			if path.Base(c) == path.Base(p) {
+				i = j
+				break
+			}
+		}
+		out = append(out, Change{Status: "R100", From: cands[i], To: p})
+		deleted[id] = append(cands[:i:i], cands[i+1:]...)
+	}
+	for _, ps := range deleted {
+		for _, p := range ps {
+			out = append(out, Change{Status: "D", Path: p})
+		}
+	}
+
+	for i := range out {
+		ch := &out[i]
+		if ch.From != "" {
+			ch.Raw = ch.Status + "\t" + ch.From + "\t" + ch.To
+		} else {
+			ch.Raw = ch.Status + "\t" + ch.Path
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return changeKey(out[i]) < changeKey(out[j]) })
+	return out
+}
+
+func changeKey(ch Change) string {
+	if ch.From != "" {
+		return ch.From + "\x00" + ch.To
+	}
+	return ch.Path
+}
+
+// source is one side of the comparison: a directory, a git ref or the
+// working tree of a repo.
+type source struct {
+	dir  string
+	ref  string
+	repo string
+}
+
+func (s source) String() string {
+	switch {
+	case s.dir != "":
+		return s.dir
+	case s.ref != "":
+		return "git:" + s.ref
+	}
+	return "worktree:" + s.repo
+}
+
+func (s source) snapshot() (snapshot, error) {
+	switch {
+	case s.dir != "":
+		return dirSnapshot(s.dir)
+	case s.ref != "":
+		return refSnapshot(s.repo, s.ref)
+	}
+	return worktreeSnapshot(s.repo)
+}
+
+// diffTrees compares two sources and reports what changed.
+func diffTrees(base, cur source) ([]Change, error) {
+	bs, err := base.snapshot()
+	if err != nil {
+		return nil, fmt.Errorf("baseline %s: %w", base, err)
+	}
+	cs, err := cur.snapshot()
+	if err != nil {
+		return nil, fmt.Errorf("current %s: %w", cur, err)
+	}
+	return compare(bs, cs), nil
+}
@@ -0,0 +1,334 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"industry_backend_go/internal/config"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const usage = `usage: configctl <command> [flags]
+
+commands:
+  validate            check a config and list every problem
+  print [--resolved]  print the config (--resolved merges override file and INDUSTRY_* env)
+  diff <a> <b>        compare two config files field by field
+  migrate --to <ver>  upgrade a config to another schema version
+  migrations          list registered schema migrations
+  env                 list INDUSTRY_* variables that override config fields (* = set)
+  original            print repo= and ref= of the stream's baseline (for $GITHUB_OUTPUT)
+
+exit codes: 0 ok, 1 invalid config or configs differ, 2 usage or io error
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var code int
+	switch cmd, args := os.Args[1], os.Args[2:]; cmd {
+	case "validate":
+		code = runValidate(args)
+	case "print":
+		code = runPrint(args)
+	case "diff":
+		code = runDiff(args)
+	case "migrate":
+		code = runMigrate(args)
+	case "migrations":
+		code = runMigrations()
+	case "env":
+		code = runEnv()
+	case "original":
+		code = runOriginal(args)
+	case "-h", "--help", "help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", cmd, usage)
+		code = 2
+	}
+	os.Exit(code)
+}
+
+func runValidate(args []string) int {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	cfgPath := fs.String("config", "./.etc/config.json", "config file")
+	overridePath := fs.String("override", "", "optional local override config (default: config.local.json next to -config)")
+	resolved := fs.Bool("resolved", false, "validate the merged config instead of the base file alone")
+	_ = fs.Parse(args)
+
+	var err error
+	if *resolved {
+		_, err = config.LoadLayered(config.Options{Path: *cfgPath, OverridePath: *overridePath, Environ: os.Environ()})
+	} else {
+		_, err = config.Load(*cfgPath)
+	}
+	if err == nil {
+		fmt.Printf("OK: %s\n", *cfgPath)
+		return 0
+	}
+
+	var verrs config.ValidationErrors
+	if errors.As(err, &verrs) {
+		fmt.Printf("FAIL: %s: %d problem(s)\n", *cfgPath, len(verrs))
+		for _, e := range verrs {
+			fmt.Println(e.Error())
+		}
+		return 1
+	}
+	fmt.Fprintln(os.Stderr, "ERROR:", err)
+	return 2
+}
+
+func runPrint(args []string) int {
+	fs := flag.NewFlagSet("print", flag.ExitOnError)
+	cfgPath := fs.String("config", "./.etc/config.json", "config file")
+	overridePath := fs.String("override", "", "optional local override config (default: config.local.json next to -config)")
+	resolved := fs.Bool("resolved", false, "merge defaults, override file and INDUSTRY_* env")
+	sources := fs.Bool("sources", false, "with --resolved: print which layer set each field instead of the config")
+	_ = fs.Parse(args)
+
+	if !*resolved {
+		cfg, err := config.Load(*cfgPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "ERROR:", err)
+			return 2
+		}
+		return printJSON(os.Stdout, cfg)
+	}
+
+	r, err := config.LoadLayered(config.Options{Path: *cfgPath, OverridePath: *overridePath, Environ: os.Environ()})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR:", err)
+		return 2
+	}
+	if !*sources {
+		return printJSON(os.Stdout, r.Config)
+	}
+	for _, fs := range r.Sources() {
+		fmt.Printf("%-36s %s\n", fs.Path, fs.Source)
+	}
+	return 0
+}
+
+func runDiff(args []string) int {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	_ = fs.Parse(args)
+	if fs.NArg() != 2 {
+		fmt.Fprintln(os.Stderr, "usage: configctl diff <a> <b>")
+		return 2
+	}
+
+	a, err := config.ReadDocument(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR:", err)
+		return 2
+	}
+	b, err := config.ReadDocument(fs.Arg(1))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR:", err)
+		return 2
+	}
+
+	fa, fb := map[string]any{}, map[string]any{}
+	flatten(a, "", fa)
+	flatten(b, "", fb)
+
+	paths := make([]string, 0, len(fa)+len(fb))
+	for p := range fa {
+		paths = append(paths, p)
+	}
+	for p := range fb {
+		if _, ok := fa[p]; !ok {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+
+	changed := 0
+	for _, p := range paths {
+		va, inA := fa[p]
+		vb, inB := fb[p]
+		switch {
+		case !inB:
+			fmt.Printf("- %s: %s\n", p, compact(va))
+		case !inA:
+			fmt.Printf("+ %s: %s\n", p, compact(vb))
+		case !reflect.DeepEqual(va, vb):
+			fmt.Printf("~ %s: %s -> %s\n", p, compact(va), compact(vb))
+		default:
+			continue
+		}
+		changed++
+	}
+	if changed == 0 {
+		fmt.Println("configs are equal")
+		return 0
+	}
+	return 1
+}
+
+func runMigrate(args []string) int {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	cfgPath := fs.String("config", "./.etc/config.json", "config file")
+	to := fs.String("to", config.SchemaVersion, "target schema version")
+	write := fs.Bool("w", false, "rewrite the config file in place instead of printing to stdout")
+	_ = fs.Parse(args)
+
+	doc, err := config.ReadDocument(*cfgPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR:", err)
+		return 2
+	}
+	from, _ := doc["version"].(string)
+	applied, err := config.Migrate(doc, *to)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR:", err)
+		return 2
+	}
+	for _, m := range applied {
+		fmt.Fprintf(os.Stderr, "applied %s: %s\n", m.Version, m.Desc)
+	}
+	if *write && config.CompareVersions(from, *to) == 0 {
+		// уже на нужной версии — файл не трогаем, даже форматирование
+		fmt.Fprintf(os.Stderr, "%s is already at %s, nothing to do\n", *cfgPath, *to)
+		return 0
+	}
+
+	// на актуальной схеме пишем через Config, чтобы сохранить привычный порядок ключей
+	var out any = doc
+	if config.CompareVersions(*to, config.SchemaVersion) == 0 {
+		b, err := json.Marshal(doc)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "ERROR:", err)
+			return 2
+		}
+		cfg, err := config.Parse(b)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "ERROR: migrated config is invalid:", err)
+			return 1
+		}
+		out = cfg
+	}
+
+	if !*write {
+		return printJSON(os.Stdout, out)
+	}
+	f, err := os.Create(*cfgPath + ".tmp")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR:", err)
+		return 2
+	}
+	code := printJSON(f, out)
+	if err := f.Close(); err != nil || code != 0 {
+		_ = os.Remove(f.Name())
+		fmt.Fprintln(os.Stderr, "ERROR: write", *cfgPath)
+		return 2
+	}
+	if err := os.Rename(f.Name(), *cfgPath); err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR:", err)
+		return 2
+	}
+	fmt.Fprintf(os.Stderr, "%s migrated to %s\n", *cfgPath, *to)
+	return 0
+}
+
+func runMigrations() int {
+	for _, m := range config.Migrations() {
+		fmt.Printf("%s: %s\n", m.Version, m.Desc)
+	}
+	fmt.Printf("oldest supported schema: %s\ncurrent schema: %s\n", config.MinVersion, config.SchemaVersion)
+	return 0
+}
+
+func runEnv() int {
+	vars := config.EnvVars()
+	names := make([]string, 0, len(vars))
+	for n := range vars {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		mark := " "
+		if _, ok := os.LookupEnv(n); ok {
+			mark = "*"
+		}
+		fmt.Printf("%s %-44s %s\n", mark, n, vars[n])
+	}
+	return 0
+}
+
+// runOriginal prints the baseline of the resolved profile, so CI checks out
+// what the tools compare against, stream overrides included.
+func runOriginal(args []string) int {
+	fs := flag.NewFlagSet("original", flag.ExitOnError)
+	cfgPath := fs.String("config", "./.etc/config.json", "config file")
+	overridePath := fs.String("override", "", "optional local override config (default: config.local.json next to -config)")
+	stream := fs.String("stream", "", "stream profile (default: $INDUSTRY_STREAM or \"stream\" from config)")
+	_ = fs.Parse(args)
+
+	r, err := config.LoadLayered(config.Options{Path: *cfgPath, OverridePath: *overridePath, Environ: os.Environ()})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR:", err)
+		return 2
+	}
+	profile, err := r.Config.Profile(*stream)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR:", err)
+		return 2
+	}
+	if profile.Original.Repo == "" {
+		fmt.Fprintf(os.Stderr, "ERROR: stream %q has no original repo\n", profile.Name)
+		return 1
+	}
+	fmt.Printf("repo=%s\nref=%s\n", profile.Original.Repo, profile.Original.Ref)
+	return 0
+}
+
+func printJSON(w io.Writer, v any) int {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "    ")
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR:", err)
+		return 2
+	}
+	return 0
+}
+
+// flatten turns nested objects into "a.b.c" keys; arrays stay whole so that
+// reordering an allow_list shows up as one change.
+func flatten(v any, path string, out map[string]any) {
+	obj, ok := v.(map[string]any)
+	if !ok || (len(obj) == 0 && path != "") {
+		out[path] = v
+		return
+	}
+	for k, el := range obj {
+		p := k
+		if path != "" {
+			p = path + "." + k
+		}
+		flatten(el, p, out)
+	}
+}
+
+func compact(v any) string {
+	if s, ok := v.(string); ok {
+		return strconv.Quote(s)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return strings.TrimSpace(string(b))
+}
@@ -0,0 +1,471 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"html/template"
+	"industry_backend_go/internal/badge"
+	"industry_backend_go/internal/config"
+	"industry_backend_go/internal/history"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const usage = `usage: dashboard [flags]
+
+Builds a self-contained HTML page with the state of every task: status,
+failed tests with their output, an excerpt of the task README and, when a
+history log is present, a timeline of the task over recorded commits. The
+page has no external assets, so it can be opened from a CI artifact or
+published to GitHub Pages as is.
+
+flags:
+`
+
+// Result is the part of a testreport package result the page shows.
+type Result struct {
+	Status         string        `json:"status"`
+	State          string        `json:"state,omitempty"` // pass|flaky|fail..., by testreport
+	Classification string        `json:"classification,omitempty"`
+	Task           string        `json:"task,omitempty"`
+	BuildErrors    []Diagnostic  `json:"build_errors,omitempty"`
+	FailedTests    []string      `json:"failed_tests,omitempty"`
+	FlakyTests     []string      `json:"flaky_tests,omitempty"`
+	Elapsed        float64       `json:"elapsed,omitempty"`
+	Counts         Counts        `json:"counts"`
+	Tests          []*TestResult `json:"tests,omitempty"`
+	Output         string        `json:"output,omitempty"`
+	Panic          *struct {
+		Message  string `json:"message"`
+		Location string `json:"location,omitempty"`
+	} `json:"panic,omitempty"`
+	Coverage *struct {
+		Percent float64 `json:"percent"`
+	} `json:"coverage,omitempty"`
+	Performance string   `json:"performance,omitempty"`
+	Deadline    string   `json:"deadline,omitempty"`
+	LateBy      string   `json:"late_by,omitempty"`
+	Score       *float64 `json:"score,omitempty"`
+	MaxScore    float64  `json:"max_score,omitempty"`
+}
+
+type Counts struct {
+	Total   int `json:"total"`
+	Passed  int `json:"passed"`
+	Failed  int `json:"failed"`
+	Flaky   int `json:"flaky"`
+	Skipped int `json:"skipped"`
+}
+
+type TestResult struct {
+	Name     string        `json:"name"`
+	Status   string        `json:"status"`
+	Output   string        `json:"output,omitempty"`
+	Subtests []*TestResult `json:"subtests,omitempty"`
+}
+
+type Diagnostic struct {
+	File    string `json:"file"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+func main() {
+	inPath := flag.String("in", "package-results.json", "testreport results")
+	historyPath := flag.String("history", history.DefaultPath, "history log for task timelines (skipped if missing)")
+	outPath := flag.String("out", "dashboard/index.html", "output html file")
+	root := flag.String("root", ".", "repo root, for task READMEs")
+	title := flag.String("title", "Прогресс по заданиям", "page title")
+	commit := flag.String("commit", "", "commit the results are for, shown in the header")
+	commitURL := flag.String("commit-url", "", "commit link prefix, e.g. https://github.com/OWNER/REPO/commit/")
+	maxOutput := flag.Int("max-output", 4000, "bytes of test output shown per test")
+	excerptLines := flag.Int("excerpt", 12, "lines of the task README shown")
+	maxPoints := flag.Int("timeline", 60, "most recent commits shown in a timeline")
+	cfgPath := flag.String("config", "./.etc/config.json", "config file")
+	overridePath := flag.String("override", "", "optional local override config (default: config.local.json next to -config)")
+	stream := flag.String("stream", "", "stream profile (default: $INDUSTRY_STREAM or \"stream\" from config)")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	resolved, err := config.LoadLayered(config.Options{Path: *cfgPath, OverridePath: *overridePath, Environ: os.Environ()})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR:", err)
+		os.Exit(2)
+	}
+	profile, err := resolved.Config.Profile(*stream)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR:", err)
+		os.Exit(2)
+	}
+
+	b, err := os.ReadFile(*inPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR:", err)
+		os.Exit(2)
+	}
+	var results map[string]Result
+	if err := json.Unmarshal(b, &results); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %s: %v\n", *inPath, err)
+		os.Exit(2)
+	}
+
+	runs, err := history.Load(*historyPath)
+	if err != nil {
+		// без истории страница всё равно полезна
+		fmt.Fprintln(os.Stderr, "WARNING: no timelines:", err)
+		runs = nil
+	}
+
+	b2 := builder{
+		root:         *root,
+		commitURL:    *commitURL,
+		maxOutput:    *maxOutput,
+		excerptLines: *excerptLines,
+		maxPoints:    *maxPoints,
+		timelines:    history.Timelines(runs),
+	}
+	p := b2.page(profile, results)
+	p.Title, p.Stream, p.Commit = *title, profile.Name, *commit
+	p.Generated = time.Now().UTC().Format("2006-01-02 15:04 UTC")
+	p.HasHistory = len(runs) > 0
+	if *commit != "" && *commitURL != "" {
+		p.CommitHref = *commitURL + *commit
+	}
+
+	var out strings.Builder
+	if err := pageTmpl.Execute(&out, p); err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR:", err)
+		os.Exit(2)
+	}
+	if err := os.MkdirAll(filepath.Dir(*outPath), 0o755); err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR:", err)
+		os.Exit(2)
+	}
+	if err := writeFile(*outPath, []byte(out.String())); err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR:", err)
+		os.Exit(2)
+	}
+	fmt.Printf("wrote %s: %d tasks, %d/%d passing\n", *outPath, len(p.Tasks), p.Passed, len(p.Tasks))
+}
+
+// page is what the template renders.
+type page struct {
+	Title      string
+	Stream     string
+	Commit     string
+	CommitHref string
+	Generated  string
+	HasHistory bool
+
+	Passed       int
+	SummaryBadge template.HTML
+	Tasks        []taskView
+}
+
+type taskView struct {
+	ID      string
+	Title   string
+	Anchor  string
+	Package string
+	Missing bool // no result for the task
+
+	State      string // pass|flaky|fail|unknown, for colours
+	StatusText string
+	Badge      template.HTML
+	Tests      string // "7/9"
+	Elapsed    string
+	Score      string
+	Coverage   string
+	Deadline   string
+	LateBy     string
+	Slow       bool // benchmark limits not met
+
+	BuildErrors []string
+	Panic       string
+	FailedTests []failedTest
+	FlakyTests  []string
+	Output      string
+
+	Excerpt  string
+	Timeline []point
+}
+
+type failedTest struct {
+	Name   string
+	Output string
+}
+
+type point struct {
+	State string
+	Label string // tooltip
+	Href  string
+}
+
+type builder struct {
+	root         string
+	commitURL    string
+	maxOutput    int
+	excerptLines int
+	maxPoints    int
+	timelines    []history.Timeline
+}
+
+func (b builder) page(profile config.Profile, results map[string]Result) page {
+	var p page
+	if len(profile.Tasks) > 0 {
+		for _, ct := range profile.Tasks {
+			tv := taskView{ID: ct.ID, Title: ct.Title, Missing: true}
+			var res Result
+			for pkg, r := range results {
+				if ct.MatchesPackage(pkg) {
+					tv.Package, res, tv.Missing = pkg, r, false
+					break
+				}
+			}
+			p.Tasks = append(p.Tasks, b.task(tv, res, ct.Dir(), ct.MatchesPackage))
+		}
+	} else {
+		// без реестра — задания из ключей отчёта, как в readmesync
+		type found struct {
+			id, pkg string
+			num     int
+		}
+		var fs []found
+		for pkg := range results {
+			if id, ok := config.TaskID(pkg); ok && profile.HasTask(id) {
+				n, _ := strconv.Atoi(id)
+				fs = append(fs, found{id: id, pkg: pkg, num: n})
+			}
+		}
+		sort.Slice(fs, func(i, j int) bool { return fs[i].num < fs[j].num })
+		for _, f := range fs {
+			tv := taskView{ID: f.id, Package: f.pkg}
+			p.Tasks = append(p.Tasks, b.task(tv, results[f.pkg], "tasks/task_"+f.id, func(s string) bool { return s == f.pkg }))
+		}
+	}
+
+	for _, t := range p.Tasks {
+		if t.State == "pass" {
+			p.Passed++
+		}
+	}
+	color := "red"
+	switch {
+	case len(p.Tasks) > 0 && p.Passed == len(p.Tasks):
+		color = "brightgreen"
+	case p.Passed*2 >= len(p.Tasks):
+		color = "orange"
+	}
+	p.SummaryBadge = svg(badge.Badge{Label: "задания", Message: fmt.Sprintf("%d/%d", p.Passed, len(p.Tasks)), Color: color})
+	return p
+}
+
+func (b builder) task(tv taskView, r Result, dir string, matches func(string) bool) taskView {
+	tv.Anchor = "task-" + strings.NewReplacer("/", "-", ".", "-").Replace(tv.ID)
+	if dir != "" {
+		title, excerpt := readmeExcerpt(filepath.Join(b.root, filepath.FromSlash(dir), "README.md"), b.excerptLines)
+		if tv.Title == "" {
+			tv.Title = title
+		}
+		tv.Excerpt = excerpt
+	}
+	if tv.Title == "" {
+		tv.Title = "task " + tv.ID
+	}
+
+	tv.State, tv.StatusText = state(r, tv.Missing)
+	color := map[string]string{"pass": "brightgreen", "flaky": "yellow", "fail": "red"}[tv.State]
+	if color == "" {
+		color = "lightgrey"
+	}
+	tv.Badge = svg(badge.Badge{Label: "task " + tv.ID, Message: tv.StatusText, Color: color})
+
+	if total := r.Counts.Total - r.Counts.Skipped; total > 0 {
+		tv.Tests = fmt.Sprintf("%d/%d", r.Counts.Passed, total)
+	}
+	if r.Elapsed > 0 {
+		tv.Elapsed = strconv.FormatFloat(r.Elapsed, 'f', 2, 64) + "s"
+	}
+	if r.Score != nil {
+		tv.Score = strconv.FormatFloat(*r.Score, 'f', -1, 64) + " / " + strconv.FormatFloat(r.MaxScore, 'f', -1, 64)
+	}
+	if r.Coverage != nil {
+		tv.Coverage = strconv.FormatFloat(r.Coverage.Percent, 'f', 1, 64) + "%"
+	}
+	if r.Deadline != "" {
+		if t, err := time.Parse(time.RFC3339, r.Deadline); err == nil {
+			tv.Deadline = t.Format("02.01.2006 15:04")
+		}
+	}
+	tv.LateBy = r.LateBy
+	tv.Slow = r.Performance == "fail"
+
+	for _, d := range r.BuildErrors {
+		tv.BuildErrors = append(tv.BuildErrors, fmt.Sprintf("%s:%d:%d: %s", d.File, d.Line, d.Column, d.Message))
+	}
+	if r.Panic != nil {
+		tv.Panic = r.Panic.Message
+		if r.Panic.Location != "" {
+			tv.Panic += " (" + r.Panic.Location + ")"
+		}
+	}
+	tv.FailedTests = b.failedTests(r)
+	tv.FlakyTests = r.FlakyTests
+	if len(tv.FailedTests) == 0 && len(tv.BuildErrors) == 0 {
+		tv.Output = b.clip(r.Output)
+	}
+
+	for _, tl := range b.timelines {
+		if !matches(tl.Package) {
+			continue
+		}
+		pts := tl.Points
+		if len(pts) > b.maxPoints {
+			pts = pts[len(pts)-b.maxPoints:]
+		}
+		for _, pt := range pts {
+			commit := pt.Commit
+			if len(commit) > 7 {
+				commit = commit[:7]
+			}
+			st, text := state(Result{Status: pt.Status, Classification: pt.Classification}, false)
+			label := fmt.Sprintf("%s %s: %s", commit, pt.When.Format("2006-01-02 15:04"), text)
+			if pt.Total > 0 {
+				label += fmt.Sprintf(", %d/%d тестов", pt.Passed, pt.Total)
+			}
+			ps := point{State: st, Label: label}
+			if b.commitURL != "" {
+				ps.Href = b.commitURL + pt.Commit
+			}
+			tv.Timeline = append(tv.Timeline, ps)
+		}
+		break
+	}
+	return tv
+}
+
+// state maps a result to a colour class and a short status text.
+func state(r Result, missing bool) (string, string) {
+	if missing {
+		return "unknown", "нет данных"
+	}
+	st := r.State
+	if st == "" {
+		st = r.Status // точки истории и старые отчёты
+	}
+	switch st {
+	case "pass":
+		return "pass", "сдано"
+	case "flaky":
+		return "flaky", "нестабильно"
+	case "fail":
+		text := map[string]string{
+			"build_error": "не собирается",
+			"panic":       "паника",
+			"timeout":     "таймаут",
+			"race":        "гонка данных",
+		}[r.Classification]
+		if text == "" {
+			text = "не сдано"
+		}
+		return "fail", text
+	}
+	return "unknown", r.Status
+}
+
+// failedTests collects the failed tests of the tree, parents before their
+// subtests, falling back to the names in failed_tests.
+func (b builder) failedTests(r Result) []failedTest {
+	var out []failedTest
+	var walk func([]*TestResult)
+	walk = func(ts []*TestResult) {
+		for _, t := range ts {
+			if t.Status == "fail" {
+				out = append(out, failedTest{Name: t.Name, Output: b.clip(t.Output)})
+			}
+			walk(t.Subtests)
+		}
+	}
+	walk(r.Tests)
+	if len(out) == 0 {
+		for _, name := range r.FailedTests {
+			out = append(out, failedTest{Name: name})
+		}
+	}
+	return out
+}
+
+func (b builder) clip(s string) string {
+	s = strings.TrimRight(s, "\n")
+	if b.maxOutput > 0 && len(s) > b.maxOutput {
+		cut := b.maxOutput
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut-- // не режем кириллицу посреди символа
+		}
+		return s[:cut] + "\n… (" + strconv.Itoa(len(s)-cut) + " more bytes)"
+	}
+	return s
+}
+
+// readmeExcerpt returns the first heading of a task README and up to n
+// lines of text after it.
+func readmeExcerpt(p string, n int) (title, excerpt string) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", ""
+	}
+	defer f.Close()
+	var lines []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), " \t")
+		if title == "" && strings.HasPrefix(line, "#") {
+			title = strings.TrimSpace(strings.TrimLeft(line, "#"))
+			continue
+		}
+		if len(lines) == 0 && strings.TrimSpace(line) == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "![") || strings.HasPrefix(line, "<") {
+			continue // бейджи и картинки
+		}
+		if len(lines) >= n {
+			lines = append(lines, "…")
+			break
+		}
+		lines = append(lines, line)
+	}
+	return title, strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+func svg(b badge.Badge) template.HTML {
+	// flat ссылается на id внутри svg, а на одной странице их несколько —
+	// flat-square обходится без id
+	s, err := badge.Render(b, badge.FlatSquare)
+	if err != nil {
+		return ""
+	}
+	// Render экранирует текст сам
+	return template.HTML(s)
+}
+
+// writeFile replaces p via a temp file.
+func writeFile(p string, data []byte) error {
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, p)
+}
@@ -0,0 +1,153 @@
+package main
+
+import "html/template"
+
+// pageTmpl is the whole page: styles are inline and there is no script, so
+// the file works when opened from disk, from a CI artifact or from Pages.
+var pageTmpl = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+:root {
+	--bg: #ffffff; --fg: #1f2328; --muted: #656d76; --border: #d0d7de; --card: #f6f8fa; --code: #f6f8fa;
+	--pass: #1a7f37; --flaky: #bf8700; --fail: #cf222e; --unknown: #8c959f;
+}
+@media (prefers-color-scheme: dark) {
+	:root {
+		--bg: #0d1117; --fg: #e6edf3; --muted: #8d96a0; --border: #30363d; --card: #161b22; --code: #161b22;
+		--pass: #3fb950; --flaky: #d29922; --fail: #f85149; --unknown: #6e7681;
+	}
+}
+* { box-sizing: border-box; }
+body { margin: 0 auto; max-width: 1200px; padding: 24px; background: var(--bg); color: var(--fg);
+	font: 14px/1.5 -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; }
+a { color: inherit; }
+header { display: flex; flex-wrap: wrap; align-items: center; gap: 12px; margin-bottom: 24px; }
+header h1 { margin: 0; font-size: 24px; flex: 1 1 auto; }
+.meta { color: var(--muted); font-size: 12px; }
+.grid { display: grid; grid-template-columns: repeat(auto-fill, minmax(220px, 1fr)); gap: 12px; margin-bottom: 32px; }
+.card { display: block; text-decoration: none; padding: 12px; border: 1px solid var(--border); border-left: 4px solid var(--unknown);
+	border-radius: 6px; background: var(--card); }
+.card:hover { border-color: var(--fg); }
+.card h2 { margin: 0 0 6px; font-size: 14px; }
+.card dl { margin: 6px 0 0; display: grid; grid-template-columns: auto 1fr; gap: 0 8px; font-size: 12px; color: var(--muted); }
+.card dd { margin: 0; color: var(--fg); }
+.pass { border-left-color: var(--pass); }
+.flaky { border-left-color: var(--flaky); }
+.fail { border-left-color: var(--fail); }
+.status { font-weight: 600; }
+.status.pass { color: var(--pass); } .status.flaky { color: var(--flaky); }
+.status.fail { color: var(--fail); } .status.unknown { color: var(--unknown); }
+.late { color: var(--fail); }
+section.task { border-top: 1px solid var(--border); padding: 16px 0; }
+section.task h2 { margin: 0 0 8px; font-size: 18px; display: flex; align-items: center; gap: 8px; flex-wrap: wrap; }
+section.task h3 { margin: 16px 0 6px; font-size: 14px; }
+pre { margin: 6px 0; padding: 8px 12px; max-height: 400px; overflow: auto; background: var(--code);
+	border: 1px solid var(--border); border-radius: 6px; font: 12px/1.45 ui-monospace, SFMono-Regular, Menlo, Consolas, monospace;
+	white-space: pre-wrap; word-break: break-word; }
+blockquote { margin: 6px 0; padding: 0 12px; color: var(--muted); border-left: 3px solid var(--border); white-space: pre-line; }
+summary { cursor: pointer; }
+ul { margin: 6px 0; padding-left: 20px; }
+.timeline { display: flex; flex-wrap: wrap; gap: 2px; }
+.timeline a, .timeline span { display: block; width: 12px; height: 12px; border-radius: 2px; background: var(--unknown); }
+.timeline .pass { background: var(--pass); }
+.timeline .fail { background: var(--fail); }
+.none { color: var(--muted); }
+</style>
+</head>
+<body>
+<header>
+<h1>{{.Title}}</h1>
+{{.SummaryBadge}}
+<div class="meta">
+{{- if .Stream}}поток {{.Stream}} · {{end -}}
+{{- if .Commit}}коммит {{if .CommitHref}}<a href="{{.CommitHref}}">{{.Commit}}</a>{{else}}{{.Commit}}{{end}} · {{end -}}
+сгенерировано {{.Generated}}
+</div>
+</header>
+
+<nav class="grid">
+{{- range .Tasks}}
+<a class="card {{.State}}" href="#{{.Anchor}}">
+<h2>{{.ID}}. {{.Title}}</h2>
+<span class="status {{.State}}">{{.StatusText}}</span>
+<dl>
+{{- if .Tests}}<dt>тесты</dt><dd>{{.Tests}}</dd>{{end}}
+{{- if .Score}}<dt>баллы</dt><dd>{{.Score}}</dd>{{end}}
+{{- if .Coverage}}<dt>покрытие</dt><dd>{{.Coverage}}</dd>{{end}}
+{{- if .Deadline}}<dt>дедлайн</dt><dd>{{.Deadline}}{{if .LateBy}} <span class="late">+{{.LateBy}}</span>{{end}}</dd>{{end}}
+</dl>
+</a>
+{{- end}}
+</nav>
+
+{{range .Tasks -}}
+<section class="task" id="{{.Anchor}}">
+<h2>{{.ID}}. {{.Title}} {{.Badge}}</h2>
+<div class="meta">
+{{- if .Package}}{{.Package}}{{else}}нет результата в отчёте{{end}}
+{{- if .Elapsed}} · {{.Elapsed}}{{end}}
+{{- if .Slow}} · <span class="late">бенчмарки не уложились в лимиты</span>{{end}}
+</div>
+
+{{- if .Excerpt}}
+<details><summary>Условие</summary>
+<blockquote>{{.Excerpt}}</blockquote>
+</details>
+{{- end}}
+
+{{- if .BuildErrors}}
+<h3>Ошибки сборки</h3>
+<pre>{{range .BuildErrors}}{{.}}
+{{end}}</pre>
+{{- end}}
+
+{{- if .Panic}}
+<h3>Паника</h3>
+<pre>{{.Panic}}</pre>
+{{- end}}
+
+{{- if .FailedTests}}
+<h3>Упавшие тесты</h3>
+{{- range .FailedTests}}
+{{- if .Output}}
+<details><summary><code>{{.Name}}</code></summary>
+<pre>{{.Output}}</pre>
+</details>
+{{- else}}
+<div><code>{{.Name}}</code></div>
+{{- end}}
+{{- end}}
+{{- end}}
+
+{{- if .FlakyTests}}
+<h3>Нестабильные тесты</h3>
+<ul>{{range .FlakyTests}}<li><code>{{.}}</code></li>{{end}}</ul>
+{{- end}}
+
+{{- if .Output}}
+<details><summary>Вывод</summary>
+<pre>{{.Output}}</pre>
+</details>
+{{- end}}
+
+{{- if $.HasHistory}}
+<h3>История</h3>
+{{- if .Timeline}}
+<div class="timeline">
+{{- range .Timeline}}
+{{- if .Href}}<a class="{{.State}}" href="{{.Href}}" title="{{.Label}}"></a>{{else}}<span class="{{.State}}" title="{{.Label}}"></span>{{end}}
+{{- end}}
+</div>
+{{- else}}
+<div class="none">нет записей</div>
+{{- end}}
+{{- end}}
+</section>
+{{end}}
+</body>
+</html>
+`))
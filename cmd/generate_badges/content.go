@@ -0,0 +1,202 @@
+package main
+
+import (
+	"fmt"
+	"industry_backend_go/internal/badge"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Result is the part of a package-results.json entry badges are made from.
+type Result struct {
+	Status         string   `json:"status"`
+	State          string   `json:"state,omitempty"` // pass|flaky|fail..., by testreport
+	Classification string   `json:"classification,omitempty"`
+	Counts         Counts   `json:"counts"`
+	OnTime         *bool    `json:"on_time,omitempty"`
+	Score          *float64 `json:"score,omitempty"`
+	MaxScore       float64  `json:"max_score,omitempty"`
+
+	// err is why the entry could not be read; such tasks get the unknown
+	// badge and are reported as failed.
+	err error
+}
+
+type Counts struct {
+	Total   int `json:"total"`
+	Passed  int `json:"passed"`
+	Skipped int `json:"skipped"`
+}
+
+// Badge message modes (-message).
+const (
+	messageAuto   = "auto"   // ok, flaky, late, build error, 7/9 tests, ...
+	messageStatus = "status" // ok / fail / unknown only
+	messageTests  = "tests"  // 7/9 tests
+	messageScore  = "score"  // 8.5/10
+)
+
+// threshold colours values at or above Min percent.
+type threshold struct {
+	Min   float64 `json:"min"`
+	Color string  `json:"color"`
+}
+
+// thresholds must be sorted by Min descending for color.
+type thresholds []threshold
+
+// parseThresholds reads "percent:colour" pairs, e.g. "100:brightgreen,50:orange,0:red".
+func parseThresholds(s string) (thresholds, error) {
+	var ts thresholds
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		p, color, ok := strings.Cut(part, ":")
+		if !ok {
+			return nil, fmt.Errorf("threshold %q: want percent:colour", part)
+		}
+		min, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil || min < 0 || min > 100 {
+			return nil, fmt.Errorf("threshold %q: percent must be a number in [0, 100]", part)
+		}
+		color = strings.TrimSpace(color)
+		if _, err := badge.ParseColor(color); err != nil {
+			return nil, fmt.Errorf("threshold %q: %v", part, err)
+		}
+		ts = append(ts, threshold{Min: min, Color: color})
+	}
+	if len(ts) == 0 {
+		return nil, fmt.Errorf("no thresholds in %q", s)
+	}
+	return ts, nil
+}
+
+func (ts thresholds) sorted() thresholds {
+	out := append(thresholds(nil), ts...)
+	sort.Slice(out, func(i, j int) bool { return out[i].Min > out[j].Min })
+	return out
+}
+
+// color returns the colour of the highest threshold pct reaches; values
+// below every threshold get the lowest one.
+func (ts thresholds) color(pct float64) string {
+	for _, t := range ts {
+		if pct >= t.Min {
+			return t.Color
+		}
+	}
+	return ts[len(ts)-1].Color
+}
+
+// content turns a task result into the badge message and colour.
+type content struct {
+	mode       string
+	tmpl       Template
+	thresholds thresholds
+}
+
+func newContent(mode string, tmpl Template) content {
+	return content{mode: mode, tmpl: tmpl, thresholds: thresholds(tmpl.Thresholds).sorted()}
+}
+
+func (c content) message(r Result) (message, color string) {
+	kind, vars, pct := c.kind(r)
+	color = c.tmpl.Colors[kind]
+	if thresholdKinds[kind] {
+		color = c.thresholds.color(pct)
+	}
+	return expand(c.tmpl.Messages[kind], vars), color
+}
+
+// kind picks the message kind for r; tests and score kinds also return
+// their placeholder values and the percentage they are coloured by.
+func (c content) kind(r Result) (kind string, vars map[string]string, pct float64) {
+	status := strings.ToLower(strings.TrimSpace(r.Status))
+	if status != "pass" && status != "fail" {
+		return kindUnknown, nil, 0
+	}
+
+	switch c.mode {
+	case messageStatus:
+		if status == "pass" {
+			return kindOK, nil, 0
+		}
+		return kindFail, nil, 0
+	case messageTests:
+		if vars, pct, ok := testVars(r); ok {
+			return kindTests, vars, pct
+		}
+	case messageScore:
+		if r.Score != nil && r.MaxScore > 0 {
+			vars := map[string]string{"score": formatNum(*r.Score), "max": formatNum(r.MaxScore)}
+			return kindScore, vars, *r.Score / r.MaxScore * 100
+		}
+	}
+
+	switch {
+	case strings.EqualFold(r.State, "flaky"):
+		return kindFlaky, nil, 0
+	case status == "pass" && r.OnTime != nil && !*r.OnTime:
+		return kindLate, nil, 0
+	case status == "pass":
+		return kindOK, nil, 0
+	}
+
+	switch r.Classification {
+	case "build_error":
+		return kindBuildError, nil, 0
+	case "timeout":
+		return kindTimeout, nil, 0
+	case "panic":
+		return kindPanic, nil, 0
+	case "race":
+		return kindRace, nil, 0
+	}
+	if vars, pct, ok := testVars(r); ok {
+		return kindTests, vars, pct
+	}
+	return kindFail, nil, 0
+}
+
+// testVars counts only stable passes as passed (a flaky test is not one)
+// and leaves skipped tests out.
+func testVars(r Result) (vars map[string]string, pct float64, ok bool) {
+	total := r.Counts.Total - r.Counts.Skipped
+	if total <= 0 {
+		return nil, 0, false
+	}
+	passed := r.Counts.Passed
+	vars = map[string]string{"passed": strconv.Itoa(passed), "total": strconv.Itoa(total)}
+	return vars, float64(passed) / float64(total) * 100, true
+}
+
+// summary is the aggregate "tasks 8/11" badge: passing tasks out of all
+// badged ones.
+func (c content) summary(tasks []Task) badge.Badge {
+	passed := 0
+	for _, t := range tasks {
+		if strings.EqualFold(strings.TrimSpace(t.Result.Status), "pass") {
+			passed++
+		}
+	}
+	pct := 0.0
+	if len(tasks) > 0 {
+		pct = float64(passed) / float64(len(tasks)) * 100
+	}
+	vars := map[string]string{"passed": strconv.Itoa(passed), "total": strconv.Itoa(len(tasks))}
+	return badge.Badge{
+		Label:      expand(c.tmpl.SummaryLabel, nil),
+		Message:    expand(c.tmpl.SummaryMessage, vars),
+		Color:      c.thresholds.color(pct),
+		LabelColor: c.tmpl.LabelColor,
+		Logo:       c.tmpl.Logo,
+	}
+}
+
+func formatNum(f float64) string {
+	return strconv.FormatFloat(math.Round(f*100)/100, 'f', -1, 64)
+}
@@ -0,0 +1,361 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"industry_backend_go/internal/badge"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// -on-error modes.
+const (
+	onErrorFailFast   = "fail-fast"
+	onErrorBestEffort = "best-effort"
+	onErrorWarn       = "warn"
+)
+
+// job is one badge file to produce.
+type job struct {
+	name     string // file name inside the output dir
+	badge    badge.Badge
+	style    string
+	fallback badge.Badge // the unknown badge written when badge cannot be
+	err      error       // input problem: write fallback right away
+}
+
+// Outcomes of a job.
+const (
+	outcomeNew       = "new"
+	outcomeChanged   = "changed"
+	outcomeUnchanged = "unchanged"
+	outcomeRemoved   = "removed"
+	outcomeSkipped   = "skipped" // not attempted after a fail-fast stop
+)
+
+type jobResult struct {
+	name     string
+	outcome  string
+	remote   bool // fetched (or would be fetched) from shields
+	fallback bool // the unknown badge was written instead
+	err      error
+}
+
+// generator renders or fetches badges into dir. A file whose content does
+// not change is left alone, so its mtime and git state stay clean.
+type generator struct {
+	dir      string
+	renderer string // local|shields
+	fallback bool
+	fetcher  fetcher
+	dryRun   bool
+	failFast bool
+
+	stop atomic.Bool
+
+	// cache remembers what each remote badge was fetched for; a badge whose
+	// inputs and file are unchanged is not fetched again.
+	cachePath string
+	mu        sync.Mutex
+	cache     map[string]cacheEntry
+	dirty     bool
+}
+
+// cacheEntry is keyed by file name in the cache file.
+type cacheEntry struct {
+	Key string `json:"key"` // hash of the badge inputs
+	Sum string `json:"sum"` // hash of the file written for them
+}
+
+// loadCache and saveCache only warn: without the cache badges are simply
+// fetched again.
+func (g *generator) loadCache() {
+	g.cache = map[string]cacheEntry{}
+	if g.cachePath == "" {
+		return
+	}
+	b, err := os.ReadFile(g.cachePath)
+	if os.IsNotExist(err) {
+		return
+	}
+	if err == nil {
+		err = json.Unmarshal(b, &g.cache)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING: ignoring badge cache %s: %v\n", g.cachePath, err)
+		g.cache = map[string]cacheEntry{}
+	}
+}
+
+func (g *generator) saveCache() {
+	if g.cachePath == "" || g.dryRun || !g.dirty {
+		return
+	}
+	b, err := json.MarshalIndent(g.cache, "", "  ")
+	if err == nil {
+		err = writeFile(g.cachePath, append(b, '\n'))
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING: badge cache %s not saved: %v\n", g.cachePath, err)
+	}
+}
+
+func (g *generator) stopped() bool { return g.stop.Load() }
+
+// run processes jobs with at most workers in flight and returns results in
+// job order.
+func (g *generator) run(jobs []job, workers int) []jobResult {
+	results := make([]jobResult, len(jobs))
+	idx := make(chan int)
+	var wg sync.WaitGroup
+	for range max(1, min(workers, len(jobs))) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range idx {
+				if g.stopped() {
+					results[i] = jobResult{name: jobs[i].name, outcome: outcomeSkipped}
+					continue
+				}
+				results[i] = g.do(jobs[i])
+				if results[i].err != nil && g.failFast {
+					g.stop.Store(true)
+				}
+			}
+		}()
+	}
+	for i := range jobs {
+		idx <- i
+	}
+	close(idx)
+	wg.Wait()
+	return results
+}
+
+func (g *generator) do(j job) jobResult {
+	res := jobResult{name: j.name}
+	path := filepath.Join(g.dir, j.name)
+	old, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		res.err = err
+		return res
+	}
+
+	var data []byte
+	if err = j.err; err == nil {
+		data, res.remote, err = g.produce(j, old)
+	}
+	if err != nil {
+		res.err = err
+		if g.failFast {
+			return res
+		}
+		// вместо сломанного бейджа — "unknown", чтобы README не остался
+		// с бейджами из разных прогонов
+		style := j.style
+		if !badge.Supported(style) {
+			style = badge.Flat
+		}
+		if data, err = badge.Render(j.fallback, style); err != nil {
+			res.err = fmt.Errorf("%w; unknown badge: %v", res.err, err)
+			return res
+		}
+		res.fallback, res.remote = true, false
+	}
+
+	switch {
+	case old == nil:
+		res.outcome = outcomeNew
+	case data == nil || bytes.Equal(old, data):
+		res.outcome = outcomeUnchanged
+		return res
+	default:
+		res.outcome = outcomeChanged
+	}
+	if data == nil || g.dryRun {
+		// в dry-run удалённый бейдж не качаем: достаточно знать, что он устарел
+		return res
+	}
+	if err := writeFile(path, data); err != nil {
+		res.err, res.fallback = err, false
+	}
+	return res
+}
+
+// produce returns the badge content. nil data with a nil error means the
+// cached remote badge is still valid (or, in dry-run, that it would be
+// fetched).
+func (g *generator) produce(j job, old []byte) (data []byte, remote bool, err error) {
+	if g.renderer == "local" {
+		svg, err := badge.Render(j.badge, j.style)
+		if err == nil || !g.fallback {
+			return svg, false, err
+		}
+		fmt.Fprintf(os.Stderr, "%s: %v; fetching from shields\n", j.name, err)
+	}
+
+	u := buildBadgeURL(j.badge, j.style)
+	key := hashOf([]byte(u))
+	g.mu.Lock()
+	ce, ok := g.cache[j.name]
+	g.mu.Unlock()
+	if ok && ce.Key == key && old != nil && ce.Sum == hashOf(old) {
+		return nil, true, nil
+	}
+	if g.dryRun {
+		if old == nil {
+			return nil, true, nil
+		}
+		// вход поменялся — файл точно будет другим; пустой data != old
+		return []byte{}, true, nil
+	}
+
+	data, err = g.fetcher.fetch(u)
+	if err != nil {
+		return nil, true, err
+	}
+	g.mu.Lock()
+	g.cache[j.name] = cacheEntry{Key: key, Sum: hashOf(data)}
+	g.dirty = true
+	g.mu.Unlock()
+	return data, true, nil
+}
+
+// prune removes task badges no job produced, e.g. of a task dropped from
+// the registry. Other files in the directory are not touched.
+func (g *generator) prune(jobs []job) []jobResult {
+	keep := map[string]bool{}
+	for _, j := range jobs {
+		keep[j.name] = true
+	}
+	stale, _ := filepath.Glob(filepath.Join(g.dir, "task_*.svg")) // шаблон заведомо корректен
+	sort.Strings(stale)
+	var out []jobResult
+	for _, p := range stale {
+		name := filepath.Base(p)
+		if keep[name] {
+			continue
+		}
+		r := jobResult{name: name, outcome: outcomeRemoved}
+		if !g.dryRun {
+			r.err = os.Remove(p)
+			if _, ok := g.cache[name]; ok {
+				delete(g.cache, name)
+				g.dirty = true
+			}
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// writeFile replaces outPath via a temp file, so a half-written badge is
+// never committed.
+func writeFile(outPath string, data []byte) error {
+	tmp := outPath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, outPath)
+}
+
+func hashOf(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// runReport is the outcome of a run; -report writes it as json.
+type runReport struct {
+	Dir      string         `json:"dir"`
+	DryRun   bool           `json:"dry_run,omitempty"`
+	Counts   map[string]int `json:"counts"` // by outcome
+	Fetched  int            `json:"fetched,omitempty"`
+	Changed  []fileChange   `json:"changed,omitempty"`
+	Failures []failure      `json:"failures,omitempty"`
+}
+
+type fileChange struct {
+	File    string `json:"file"`
+	Outcome string `json:"outcome"`
+}
+
+type failure struct {
+	File     string `json:"file"`
+	Error    string `json:"error"`
+	Fallback bool   `json:"fallback"` // the unknown badge was written instead
+}
+
+func summarize(results []jobResult, dir string, dryRun bool) runReport {
+	rep := runReport{Dir: dir, DryRun: dryRun, Counts: map[string]int{}}
+	for _, r := range results {
+		file := filepath.Join(dir, r.name)
+		if r.err != nil {
+			rep.Failures = append(rep.Failures, failure{File: file, Error: r.err.Error(), Fallback: r.fallback})
+		}
+		if r.outcome == "" {
+			continue
+		}
+		rep.Counts[r.outcome]++
+		if r.remote && r.outcome != outcomeUnchanged {
+			rep.Fetched++
+		}
+		if r.outcome != outcomeUnchanged && r.outcome != outcomeSkipped {
+			rep.Changed = append(rep.Changed, fileChange{File: file, Outcome: r.outcome})
+		}
+	}
+	return rep
+}
+
+// print lists changed files and counts to out, failures to errOut.
+func (rep runReport) print(out, errOut io.Writer) {
+	for _, c := range rep.Changed {
+		fmt.Fprintf(out, "  %-9s %s\n", c.Outcome, c.File)
+	}
+	var parts []string
+	for _, o := range []string{outcomeNew, outcomeChanged, outcomeRemoved, outcomeUnchanged, outcomeSkipped} {
+		if n := rep.Counts[o]; n > 0 || o != outcomeSkipped {
+			parts = append(parts, fmt.Sprintf("%d %s", n, o))
+		}
+	}
+	msg := fmt.Sprintf("badges in %s: %s", rep.Dir, strings.Join(parts, ", "))
+	if rep.Fetched > 0 {
+		verb := ""
+		if rep.DryRun {
+			verb = "would be "
+		}
+		msg += fmt.Sprintf(" (%d %sfetched from shields)", rep.Fetched, verb)
+	}
+	if rep.DryRun {
+		msg += "; dry run, nothing written"
+	}
+	fmt.Fprintln(out, msg)
+
+	if len(rep.Failures) == 0 {
+		return
+	}
+	fmt.Fprintf(errOut, "%d badge(s) failed:\n", len(rep.Failures))
+	for _, f := range rep.Failures {
+		note := ""
+		if f.Fallback {
+			note = " (unknown badge written)"
+		}
+		fmt.Fprintf(errOut, "  %s: %s%s\n", f.File, f.Error, note)
+	}
+}
+
+func (rep runReport) write(path string) error {
+	b, err := json.MarshalIndent(rep, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0o644)
+}
@@ -4,9 +4,9 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
-	"io"
+	"industry_backend_go/internal/badge"
+	"industry_backend_go/internal/config"
 	"net/http"
-	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -16,15 +16,13 @@ import (
 	"time"
 )
 
-type Result struct {
-	Status string `json:"status"`
-}
-
 type Task struct {
 	Key    string // исходный ключ из json
-	Num    int    // для сортировки
+	Num    int    // для сортировки (порядок в реестре или номер задания)
 	ID     string // "00"
-	Status string
+	Dir    string // from the repo root, for the README title
+	Title  string // registry title
+	Result Result
 }
 
 var taskRe = regexp.MustCompile(`task_(\d+)`)
@@ -32,16 +30,199 @@ var taskRe = regexp.MustCompile(`task_(\d+)`)
 func main() {
 	inPath := flag.String("in", "package-results.json", "input json path")
 	outDir := flag.String("out", "badges/tasks", "output directory for .svg files")
-	style := flag.String("style", "flat", "shields style (flat, flat-square, for-the-badge, etc.)")
-	unknownMsg := flag.String("unknown", "unknown", "message for unknown status (e.g. unknown or unknow)")
-	timeout := flag.Duration("timeout", 20*time.Second, "http timeout")
+	style := flag.String("style", "", "badge style for every badge: flat, flat-square, for-the-badge; other shields styles need -renderer shields (default: from the template)")
+	renderer := flag.String("renderer", "local", "badge renderer: local (built-in SVG) or shields (fetch from img.shields.io)")
+	fallback := flag.Bool("shields-fallback", false, "fetch from img.shields.io when the local renderer cannot draw a badge")
+	tmplPath := flag.String("template", "./.etc/badges.json", "badge template: labels, messages, colours, logo, styles and themes")
+	theme := flag.String("theme", "", "theme from the template to apply (e.g. dark)")
+	root := flag.String("root", ".", "repo root, for task README titles")
+	unknownMsg := flag.String("unknown", "", "message for unknown status (default: from the template)")
+	message := flag.String("message", messageAuto, "badge message: auto (ok, flaky, late, build error, 7/9 tests, ...), status, tests or score")
+	thresholdsFlag := flag.String("thresholds", "", "colours for test ratios and scores as percent:colour pairs, e.g. 100:brightgreen,50:orange,0:red (default: from the template)")
+	summaryName := flag.String("summary", "summary.svg", "file name of the aggregate \"tasks 8/11\" badge in -out (empty: none)")
+	timeout := flag.Duration("timeout", 20*time.Second, "http timeout (shields renderer)")
+	retries := flag.Int("retries", 3, "retries of a failed shields request")
+	backoff := flag.Duration("backoff", 500*time.Millisecond, "delay before the first retry, doubled on every next one")
+	workers := flag.Int("j", 4, "badges rendered or fetched concurrently")
+	cacheName := flag.String("cache", ".cache.json", "file in -out remembering fetched badges, so unchanged ones are not fetched again (empty: no cache)")
+	prune := flag.Bool("prune", true, "remove task badges of tasks no longer badged")
+	dryRun := flag.Bool("dry-run", false, "report which files would change without writing anything")
+	onError := flag.String("on-error", onErrorBestEffort, "fail-fast: stop at the first failed badge, exit 1; best-effort: write an unknown badge instead of each failed one, exit 1; warn: like best-effort, exit 0")
+	reportPath := flag.String("report", "", "write a json summary of the run, including failed badges and why")
+	cfgPath := flag.String("config", "./.etc/config.json", "config file")
+	overridePath := flag.String("override", "", "optional local override config (default: config.local.json next to -config)")
+	stream := flag.String("stream", "", "stream profile (default: $INDUSTRY_STREAM or \"stream\" from config)")
 	flag.Parse()
 
-	b, err := os.ReadFile(*inPath)
-	must(err)
+	explicit := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+
+	switch *message {
+	case messageAuto, messageStatus, messageTests, messageScore:
+	default:
+		fatal(fmt.Errorf("unknown -message %q (want auto, status, tests or score)", *message))
+	}
+	switch *onError {
+	case onErrorFailFast, onErrorBestEffort, onErrorWarn:
+	default:
+		fatal(fmt.Errorf("unknown -on-error %q (want fail-fast, best-effort or warn)", *onError))
+	}
+	if *retries < 0 {
+		fatal(fmt.Errorf("-retries must not be negative, got %d", *retries))
+	}
+	if *backoff < 0 {
+		fatal(fmt.Errorf("-backoff must not be negative, got %v", *backoff))
+	}
+
+	tmpl, err := loadTemplate(*tmplPath, explicit["template"], *theme)
+	if err != nil {
+		fatal(err)
+	}
+	// флаги сильнее шаблона
+	if *style != "" {
+		tmpl.Style = *style
+		for id, tt := range tmpl.Tasks {
+			tt.Style = ""
+			tmpl.Tasks[id] = tt
+		}
+	}
+	if *unknownMsg != "" {
+		tmpl.Messages[kindUnknown] = *unknownMsg
+	}
+	if *thresholdsFlag != "" {
+		ts, err := parseThresholds(*thresholdsFlag)
+		if err != nil {
+			fatal(err)
+		}
+		tmpl.Thresholds = ts
+	}
+	cont := newContent(*message, tmpl)
+
+	switch *renderer {
+	case "local":
+		for _, st := range tmpl.styles() {
+			if !badge.Supported(st) && !*fallback {
+				fatal(fmt.Errorf("style %q is not rendered locally (want one of %s); use -renderer shields or -shields-fallback",
+					st, strings.Join(badge.Styles(), ", ")))
+			}
+		}
+	case "shields":
+	default:
+		fatal(fmt.Errorf("unknown -renderer %q (want local or shields)", *renderer))
+	}
+
+	resolved, err := config.LoadLayered(config.Options{Path: *cfgPath, OverridePath: *overridePath, Environ: os.Environ()})
+	if err != nil {
+		fatal(err)
+	}
+	profile, err := resolved.Config.Profile(*stream)
+	if err != nil {
+		fatal(err)
+	}
+
+	m, inErr := readResults(*inPath)
+	if inErr != nil && len(profile.Tasks) == 0 {
+		// без реестра не из чего даже составить список бейджей
+		fatal(inErr)
+	}
+	tasks := collectTasks(profile, m)
+	if inErr != nil {
+		for i := range tasks {
+			tasks[i].Result.err = inErr
+		}
+	}
+
+	var jobs []job
+	for _, t := range tasks {
+		b, st := tmpl.taskBadge(t, *root)
+		unknown := b
+		unknown.Message, unknown.Color = cont.message(Result{Status: "unknown"})
+		b.Message, b.Color = cont.message(t.Result)
+		jobs = append(jobs, job{name: fmt.Sprintf("task_%s.svg", t.ID), badge: b, style: st, fallback: unknown, err: t.Result.err})
+	}
+	if *summaryName != "" {
+		sb := cont.summary(tasks)
+		unknown := sb
+		unknown.Message, unknown.Color = cont.message(Result{Status: "unknown"})
+		jobs = append(jobs, job{name: *summaryName, badge: sb, style: tmpl.Style, fallback: unknown})
+	}
+
+	if !*dryRun {
+		if err := os.MkdirAll(*outDir, 0o755); err != nil {
+			fatal(err)
+		}
+	}
+	g := &generator{
+		dir:      *outDir,
+		renderer: *renderer,
+		fallback: *fallback,
+		fetcher:  fetcher{client: &http.Client{Timeout: *timeout}, retries: *retries, backoff: *backoff},
+		dryRun:   *dryRun,
+		failFast: *onError == onErrorFailFast,
+	}
+	if *cacheName != "" {
+		g.cachePath = filepath.Join(*outDir, *cacheName)
+	}
+	g.loadCache()
+
+	results := g.run(jobs, *workers)
+	if *prune && !g.stopped() {
+		results = append(results, g.prune(jobs)...)
+	}
+	g.saveCache()
+
+	rep := summarize(results, *outDir, *dryRun)
+	rep.print(os.Stdout, os.Stderr)
+	if *reportPath != "" {
+		if err := rep.write(*reportPath); err != nil {
+			fatal(err)
+		}
+	}
+	if len(rep.Failures) > 0 && *onError != onErrorWarn {
+		os.Exit(1)
+	}
+}
+
+// readResults decodes package-results.json entry by entry, so one
+// malformed entry only costs its own badge.
+func readResults(path string) (map[string]Result, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	m := make(map[string]Result, len(raw))
+	for k, v := range raw {
+		var r Result
+		if err := json.Unmarshal(v, &r); err != nil {
+			r = Result{Status: "unknown", err: fmt.Errorf("%s: entry %q: %w", path, k, err)}
+		}
+		m[k] = r
+	}
+	return m, nil
+}
 
-	var m map[string]Result
-	must(json.Unmarshal(b, &m))
+// collectTasks builds the badge set. With a task registry every enabled
+// task of the stream gets a badge, even if the report has no entry for it;
+// otherwise tasks are discovered from the report keys.
+func collectTasks(profile config.Profile, m map[string]Result) []Task {
+	if len(profile.Tasks) > 0 {
+		tasks := make([]Task, 0, len(profile.Tasks))
+		for i, ct := range profile.Tasks {
+			t := Task{Num: i, ID: ct.ID, Dir: ct.Dir(), Title: ct.Title, Result: Result{Status: "unknown"}}
+			for k, r := range m {
+				if ct.MatchesPackage(k) {
+					t.Key, t.Result = k, r
+					break
+				}
+			}
+			tasks = append(tasks, t)
+		}
+		return tasks
+	}
 
 	tasks := make([]Task, 0, len(m))
 	for k, r := range m {
@@ -50,11 +231,15 @@ func main() {
 			// если в json есть ключи не про task_XX — пропускаем
 			continue
 		}
+		if !profile.HasTask(id) {
+			continue
+		}
 		tasks = append(tasks, Task{
 			Key:    k,
 			Num:    num,
 			ID:     id,
-			Status: r.Status,
+			Dir:    "tasks/task_" + id,
+			Result: r,
 		})
 	}
 
@@ -64,25 +249,7 @@ func main() {
 		}
 		return tasks[i].Key < tasks[j].Key
 	})
-
-	must(os.MkdirAll(*outDir, 0o755))
-
-	client := &http.Client{Timeout: *timeout}
-
-	written := 0
-	for _, t := range tasks {
-		msg, color := mapStatus(t.Status, *unknownMsg)
-		label := "task " + t.ID
-
-		badgeURL := buildBadgeURL(label, msg, color, *style)
-
-		outPath := filepath.Join(*outDir, fmt.Sprintf("task_%s.svg", t.ID))
-		must(downloadToFile(client, badgeURL, outPath))
-
-		written++
-	}
-
-	fmt.Printf("generated %d svg badge files in %s\n", written, *outDir)
+	return tasks
 }
 
 func extractTaskID(key string) (id string, num int, ok bool) {
@@ -98,79 +265,8 @@ func extractTaskID(key string) (id string, num int, ok bool) {
 	return id, n, true
 }
 
-func mapStatus(status, unknownMsg string) (message, color string) {
-	switch strings.ToLower(strings.TrimSpace(status)) {
-	case "pass":
-		return "ok", "brightgreen"
-	case "fail":
-		return "fail", "red"
-	default:
-		return unknownMsg, "lightgrey"
-	}
-}
-
-func buildBadgeURL(label, message, color, style string) string {
-	// Важно: в /badge/ используется формат LABEL-MESSAGE-COLOR.
-	// Экранируем каждый сегмент отдельно, чтобы пробелы стали %20.
-	l := url.PathEscape(label)
-	m := url.PathEscape(message)
-	c := url.PathEscape(color)
-
-	u := fmt.Sprintf("https://img.shields.io/badge/%s-%s-%s.svg", l, m, c)
-
-	v := url.Values{}
-	if style != "" {
-		v.Set("style", style)
-	}
-	// Можно добавить cacheSeconds, если хочешь:
-	// v.Set("cacheSeconds", "60")
-
-	if qs := v.Encode(); qs != "" {
-		u += "?" + qs
-	}
-	return u
-}
-
-func downloadToFile(client *http.Client, u, outPath string) error {
-	req, err := http.NewRequest("GET", u, nil)
-	if err != nil {
-		return err
-	}
-	req.Header.Set("User-Agent", "badgesvg/1.0 (+github actions)")
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		// чтобы увидеть текст ошибки shields, но не читать бесконечно
-		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
-		return fmt.Errorf("GET %s: status %d: %s", u, resp.StatusCode, strings.TrimSpace(string(body)))
-	}
-
-	tmp := outPath + ".tmp"
-	f, err := os.Create(tmp)
-	if err != nil {
-		return err
-	}
-	_, copyErr := io.Copy(f, resp.Body)
-	closeErr := f.Close()
-	if copyErr != nil {
-		_ = os.Remove(tmp)
-		return copyErr
-	}
-	if closeErr != nil {
-		_ = os.Remove(tmp)
-		return closeErr
-	}
-
-	return os.Rename(tmp, outPath)
-}
-
-func must(err error) {
-	if err != nil {
-		panic(err)
-	}
+// fatal reports a setup error, when no badge can be made at all.
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "ERROR:", err)
+	os.Exit(2)
 }
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"industry_backend_go/internal/badge"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func buildBadgeURL(b badge.Badge, style string) string {
+	// Важно: в /badge/ используется формат LABEL-MESSAGE-COLOR.
+	// Экранируем каждый сегмент отдельно, чтобы пробелы стали %20,
+	// а "-" и "_" удваиваем — иначе shields примет их за разделитель и пробел.
+	seg := strings.NewReplacer("-", "--", "_", "__")
+	l := url.PathEscape(seg.Replace(b.Label))
+	m := url.PathEscape(seg.Replace(b.Message))
+	c := url.PathEscape(strings.TrimPrefix(b.Color, "#"))
+
+	u := fmt.Sprintf("https://img.shields.io/badge/%s-%s-%s.svg", l, m, c)
+
+	v := url.Values{}
+	if style != "" {
+		v.Set("style", style)
+	}
+	if b.LabelColor != "" {
+		v.Set("labelColor", strings.TrimPrefix(b.LabelColor, "#"))
+	}
+	if b.Logo != "" {
+		v.Set("logo", b.Logo)
+	}
+	// Можно добавить cacheSeconds, если хочешь:
+	// v.Set("cacheSeconds", "60")
+
+	if qs := v.Encode(); qs != "" {
+		u += "?" + qs
+	}
+	return u
+}
+
+// fetcher downloads badges from shields, retrying network errors, 429 and
+// 5xx with exponential backoff.
+type fetcher struct {
+	client  *http.Client
+	retries int           // attempts after the first one
+	backoff time.Duration // first delay; doubled on every retry
+}
+
+// retryable marks errors worth another attempt.
+type retryable struct {
+	err   error
+	after time.Duration // Retry-After, if the server sent one
+}
+
+func (e *retryable) Error() string { return e.err.Error() }
+func (e *retryable) Unwrap() error { return e.err }
+
+func (f fetcher) fetch(u string) ([]byte, error) {
+	delay := f.backoff
+	for attempt := 0; ; attempt++ {
+		body, err := f.get(u)
+		var r *retryable
+		if err == nil || !errors.As(err, &r) || attempt >= f.retries {
+			if err != nil && attempt > 0 {
+				err = fmt.Errorf("%w (after %d attempts)", err, attempt+1)
+			}
+			return body, err
+		}
+		wait := delay + rand.N(delay/2+1) // джиттер, чтобы воркеры не били в shields разом
+		if r.after > wait {
+			wait = r.after
+		}
+		time.Sleep(wait)
+		delay *= 2
+	}
+}
+
+func (f fetcher) get(u string) ([]byte, error) {
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "badgesvg/1.0 (+github actions)")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, &retryable{err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		// чтобы увидеть текст ошибки shields, но не читать бесконечно
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		err := fmt.Errorf("GET %s: status %d: %s", u, resp.StatusCode, strings.TrimSpace(string(body)))
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			return nil, &retryable{err: err, after: retryAfter(resp.Header.Get("Retry-After"))}
+		}
+		return nil, err
+	}
+
+	// бейдж — пара килобайт; больше — это уже не svg
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, &retryable{err: err}
+	}
+	return body, nil
+}
+
+// retryAfter parses the seconds form of Retry-After; the date form is rare
+// enough to ignore. Waits are capped so a misbehaving server cannot stall CI.
+func retryAfter(h string) time.Duration {
+	sec, err := strconv.Atoi(strings.TrimSpace(h))
+	if err != nil || sec <= 0 {
+		return 0
+	}
+	return min(time.Duration(sec)*time.Second, time.Minute)
+}
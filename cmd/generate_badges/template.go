@@ -0,0 +1,392 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"industry_backend_go/internal/badge"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Template describes how badges look: label format, message and colour per
+// message kind, logo and style. The built-in defaults reproduce the plain
+// "task 00: ok" badges; a template file overrides any part of them, and a
+// theme (-theme) overrides the template the same way.
+type Template struct {
+	Style      string `json:"style,omitempty"`
+	Label      string `json:"label,omitempty"` // {id}, {num}, {title}
+	LabelColor string `json:"label_color,omitempty"`
+	// Logo is a data: URI or a path to an .svg/.png file relative to the
+	// template file; it is embedded into every badge.
+	Logo string `json:"logo,omitempty"`
+	// TitleTrim are prefixes cut off README headings for {title}, e.g.
+	// "Практическое задание:".
+	TitleTrim []string `json:"title_trim,omitempty"`
+
+	// Messages and Colors are keyed by message kind (ok, fail, flaky, ...).
+	// Messages may use {passed}, {total}, {score} and {max}; tests and
+	// score badges are coloured by Thresholds.
+	Messages   map[string]string `json:"messages,omitempty"`
+	Colors     map[string]string `json:"colors,omitempty"`
+	Thresholds []threshold       `json:"thresholds,omitempty"`
+
+	SummaryLabel   string `json:"summary_label,omitempty"`
+	SummaryMessage string `json:"summary_message,omitempty"` // {passed}, {total}
+
+	Tasks  map[string]TaskTemplate `json:"tasks,omitempty"`  // by task id
+	Themes map[string]Template     `json:"themes,omitempty"` // by -theme name
+}
+
+// TaskTemplate overrides the template for one task.
+type TaskTemplate struct {
+	Label      string `json:"label,omitempty"`
+	LabelColor string `json:"label_color,omitempty"`
+	Style      string `json:"style,omitempty"`
+	Logo       string `json:"logo,omitempty"`
+}
+
+// Message kinds.
+const (
+	kindOK         = "ok"
+	kindFail       = "fail"
+	kindUnknown    = "unknown"
+	kindFlaky      = "flaky"
+	kindLate       = "late"
+	kindBuildError = "build_error"
+	kindTimeout    = "timeout"
+	kindPanic      = "panic"
+	kindRace       = "race"
+	kindTests      = "tests"
+	kindScore      = "score"
+)
+
+func defaultTemplate() Template {
+	return Template{
+		Style: badge.Flat,
+		Label: "task {id}",
+		Messages: map[string]string{
+			kindOK:         "ok",
+			kindFail:       "fail",
+			kindUnknown:    "unknown",
+			kindFlaky:      "flaky",
+			kindLate:       "late",
+			kindBuildError: "build error",
+			kindTimeout:    "timeout",
+			kindPanic:      "panic",
+			kindRace:       "data race",
+			kindTests:      "{passed}/{total} tests",
+			kindScore:      "{score}/{max}",
+		},
+		Colors: map[string]string{
+			kindOK:         "brightgreen",
+			kindFail:       "red",
+			kindUnknown:    "lightgrey",
+			kindFlaky:      "yellow",
+			kindLate:       "orange",
+			kindBuildError: "red",
+			kindTimeout:    "red",
+			kindPanic:      "red",
+			kindRace:       "red",
+		},
+		Thresholds: []threshold{
+			{Min: 100, Color: "brightgreen"},
+			{Min: 80, Color: "yellow"},
+			{Min: 50, Color: "orange"},
+			{Min: 0, Color: "red"},
+		},
+		SummaryLabel:   "tasks",
+		SummaryMessage: "{passed}/{total}",
+	}
+}
+
+// loadTemplate reads a template file over the defaults and applies theme.
+// A missing file is fine unless required.
+func loadTemplate(path string, required bool, theme string) (Template, error) {
+	t := defaultTemplate()
+	if path != "" {
+		b, err := os.ReadFile(path)
+		switch {
+		case os.IsNotExist(err) && !required:
+		case err != nil:
+			return Template{}, err
+		default:
+			var file Template
+			dec := json.NewDecoder(bytes.NewReader(b))
+			dec.DisallowUnknownFields()
+			if err := dec.Decode(&file); err != nil {
+				return Template{}, fmt.Errorf("%s: %w", path, err)
+			}
+			t = t.merge(file)
+			t.Themes = file.Themes
+		}
+	}
+
+	if theme != "" {
+		th, ok := t.Themes[theme]
+		if !ok {
+			return Template{}, fmt.Errorf("theme %q is not defined in %s", theme, path)
+		}
+		if len(th.Themes) > 0 {
+			return Template{}, fmt.Errorf("theme %q: themes cannot be nested", theme)
+		}
+		t = t.merge(th)
+	}
+
+	if err := t.resolveLogos(filepath.Dir(path)); err != nil {
+		return Template{}, err
+	}
+	return t, t.validate()
+}
+
+// merge returns t with the non-empty fields of o on top; maps are merged
+// key by key.
+func (t Template) merge(o Template) Template {
+	set := func(dst *string, v string) {
+		if v != "" {
+			*dst = v
+		}
+	}
+	set(&t.Style, o.Style)
+	set(&t.Label, o.Label)
+	set(&t.LabelColor, o.LabelColor)
+	set(&t.Logo, o.Logo)
+	set(&t.SummaryLabel, o.SummaryLabel)
+	set(&t.SummaryMessage, o.SummaryMessage)
+	if o.TitleTrim != nil {
+		t.TitleTrim = o.TitleTrim
+	}
+	if o.Thresholds != nil {
+		t.Thresholds = o.Thresholds
+	}
+	t.Messages = mergeMap(t.Messages, o.Messages)
+	t.Colors = mergeMap(t.Colors, o.Colors)
+
+	tasks := make(map[string]TaskTemplate, len(t.Tasks)+len(o.Tasks))
+	for id, tt := range t.Tasks {
+		tasks[id] = tt
+	}
+	for id, ot := range o.Tasks {
+		tt := tasks[id]
+		set(&tt.Label, ot.Label)
+		set(&tt.LabelColor, ot.LabelColor)
+		set(&tt.Style, ot.Style)
+		set(&tt.Logo, ot.Logo)
+		tasks[id] = tt
+	}
+	t.Tasks = tasks
+	return t
+}
+
+func mergeMap(a, b map[string]string) map[string]string {
+	out := make(map[string]string, len(a)+len(b))
+	for k, v := range a {
+		out[k] = v
+	}
+	for k, v := range b {
+		out[k] = v
+	}
+	return out
+}
+
+var (
+	placeholderRe  = regexp.MustCompile(`\{(\w+)\}`)
+	labelVars      = []string{"id", "num", "title"}
+	messageVars    = []string{"passed", "total", "score", "max"}
+	messageKinds   = []string{kindOK, kindFail, kindUnknown, kindFlaky, kindLate, kindBuildError, kindTimeout, kindPanic, kindRace, kindTests, kindScore}
+	thresholdKinds = map[string]bool{kindTests: true, kindScore: true}
+)
+
+func (t Template) validate() error {
+	var errs []string
+	add := func(format string, args ...any) { errs = append(errs, fmt.Sprintf(format, args...)) }
+	checkColor := func(field, c string) {
+		if c == "" {
+			return
+		}
+		if _, err := badge.ParseColor(c); err != nil {
+			add("%s: %v", field, err)
+		}
+	}
+	checkVars := func(field, s string, allowed []string) {
+		for _, m := range placeholderRe.FindAllStringSubmatch(s, -1) {
+			if !contains(allowed, m[1]) {
+				add("%s: unknown placeholder {%s} (want one of {%s})", field, m[1], strings.Join(allowed, "}, {"))
+			}
+		}
+	}
+
+	checkVars("label", t.Label, labelVars)
+	checkColor("label_color", t.LabelColor)
+	checkVars("summary_label", t.SummaryLabel, nil)
+	checkVars("summary_message", t.SummaryMessage, []string{"passed", "total"})
+	for k, m := range t.Messages {
+		if !contains(messageKinds, k) {
+			add("messages.%s: unknown kind (want one of %s)", k, strings.Join(messageKinds, ", "))
+		}
+		checkVars("messages."+k, m, messageVars)
+	}
+	for _, k := range messageKinds {
+		if t.Messages[k] == "" {
+			add("messages.%s: empty", k)
+		}
+		if t.Colors[k] == "" && !thresholdKinds[k] {
+			add("colors.%s: empty", k)
+		}
+	}
+	for k, c := range t.Colors {
+		if !contains(messageKinds, k) || thresholdKinds[k] {
+			add("colors.%s: unknown kind (tests and score use thresholds)", k)
+		}
+		checkColor("colors."+k, c)
+	}
+	if len(t.Thresholds) == 0 {
+		add("thresholds: empty")
+	}
+	for i, th := range t.Thresholds {
+		if th.Min < 0 || th.Min > 100 {
+			add("thresholds[%d].min: must be in [0, 100]", i)
+		}
+		checkColor(fmt.Sprintf("thresholds[%d].color", i), th.Color)
+	}
+	for id, tt := range t.Tasks {
+		checkVars("tasks."+id+".label", tt.Label, labelVars)
+		checkColor("tasks."+id+".label_color", tt.LabelColor)
+	}
+
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return fmt.Errorf("badge template:\n  %s", strings.Join(errs, "\n  "))
+	}
+	return nil
+}
+
+// styles returns every style the template uses.
+func (t Template) styles() []string {
+	seen := map[string]bool{t.Style: true}
+	out := []string{t.Style}
+	for _, tt := range t.Tasks {
+		if tt.Style != "" && !seen[tt.Style] {
+			seen[tt.Style] = true
+			out = append(out, tt.Style)
+		}
+	}
+	sort.Strings(out[1:])
+	return out
+}
+
+// resolveLogos replaces logo file paths with data: URIs.
+func (t *Template) resolveLogos(dir string) error {
+	var err error
+	if t.Logo, err = logoURI(dir, t.Logo); err != nil {
+		return err
+	}
+	for id, tt := range t.Tasks {
+		if tt.Logo, err = logoURI(dir, tt.Logo); err != nil {
+			return fmt.Errorf("tasks.%s: %w", id, err)
+		}
+		t.Tasks[id] = tt
+	}
+	return nil
+}
+
+func logoURI(dir, logo string) (string, error) {
+	if logo == "" || strings.HasPrefix(logo, "data:") {
+		return logo, nil
+	}
+	var mime string
+	switch strings.ToLower(filepath.Ext(logo)) {
+	case ".svg":
+		mime = "image/svg+xml"
+	case ".png":
+		mime = "image/png"
+	default:
+		return "", fmt.Errorf("logo %s: want .svg, .png or a data: URI", logo)
+	}
+	p := logo
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(dir, p)
+	}
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return "", fmt.Errorf("logo: %w", err)
+	}
+	return "data:" + mime + ";base64," + base64.StdEncoding.EncodeToString(b), nil
+}
+
+// taskBadge fills label, label colour, logo and style for t.
+func (tm Template) taskBadge(t Task, root string) (badge.Badge, string) {
+	tt := tm.Tasks[t.ID]
+	label, labelColor, logo, style := tm.Label, tm.LabelColor, tm.Logo, tm.Style
+	if tt.Label != "" {
+		label = tt.Label
+	}
+	if tt.LabelColor != "" {
+		labelColor = tt.LabelColor
+	}
+	if tt.Logo != "" {
+		logo = tt.Logo
+	}
+	if tt.Style != "" {
+		style = tt.Style
+	}
+
+	vars := map[string]string{"id": t.ID, "num": strconv.Itoa(t.Num)}
+	if strings.Contains(label, "{title}") {
+		vars["title"] = tm.title(t, root)
+	}
+	return badge.Badge{
+		Label:      expand(label, vars),
+		LabelColor: labelColor,
+		Logo:       logo,
+	}, style
+}
+
+// title is the first heading of the task README, or the registry title.
+func (tm Template) title(t Task, root string) string {
+	if f, err := os.Open(filepath.Join(root, filepath.FromSlash(t.Dir), "README.md")); err == nil {
+		defer f.Close()
+		sc := bufio.NewScanner(f)
+		for sc.Scan() {
+			line := strings.TrimSpace(sc.Text())
+			if !strings.HasPrefix(line, "#") {
+				continue
+			}
+			h := strings.TrimSpace(strings.TrimLeft(line, "#"))
+			for _, p := range tm.TitleTrim {
+				h = strings.TrimSpace(strings.TrimPrefix(h, p))
+			}
+			if h != "" {
+				return h
+			}
+		}
+	}
+	if t.Title != "" {
+		return t.Title
+	}
+	return "task " + t.ID
+}
+
+func expand(s string, vars map[string]string) string {
+	return placeholderRe.ReplaceAllStringFunc(s, func(m string) string {
+		if v, ok := vars[m[1:len(m)-1]]; ok {
+			return v
+		}
+		return m
+	})
+}
+
+func contains(xs []string, x string) bool {
+	for _, v := range xs {
+		if v == x {
+			return true
+		}
+	}
+	return false
+}
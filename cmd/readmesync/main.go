@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"industry_backend_go/internal/config"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const usage = `usage: readmesync [flags]
+
+Rewrites the generated regions of the README from the task registry, so
+new tasks get their badge, link and table row without editing by hand.
+A region is everything between
+
+  <!-- readmesync:begin NAME -->
+  <!-- readmesync:end NAME -->
+
+NAME is one of:
+  badges  the summary badge and a badge per task
+  tasks   table of tasks: link to the task README, status badge, deadline
+
+Text outside the regions is kept as is. Without a task registry, tasks are
+taken from the keys of -in.
+
+exit codes: 0 README up to date (or rewritten), 1 -check found a stale
+region, 2 usage or io error
+
+flags:
+`
+
+// Task is one README entry.
+type Task struct {
+	ID       string
+	Title    string
+	Dir      string // from the repo root
+	Deadline string // formatted, empty if none
+}
+
+func main() {
+	readme := flag.String("readme", "README.md", "README to update")
+	check := flag.Bool("check", false, "do not write; exit 1 if a region is out of date")
+	inPath := flag.String("in", "package-results.json", "test results, only for tasks without a registry")
+	badgeDir := flag.String("badges", "badges/tasks", "badge directory, relative to the README")
+	darkDir := flag.String("dark-badges", "badges/tasks-dark", "dark theme badge directory (generate_badges -theme dark); empty: no dark variant")
+	summaryName := flag.String("summary", "summary.svg", "aggregate badge file name in -badges (empty: none)")
+	cfgPath := flag.String("config", "./.etc/config.json", "config file")
+	overridePath := flag.String("override", "", "optional local override config (default: config.local.json next to -config)")
+	stream := flag.String("stream", "", "stream profile (default: $INDUSTRY_STREAM or \"stream\" from config)")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	resolved, err := config.LoadLayered(config.Options{Path: *cfgPath, OverridePath: *overridePath, Environ: os.Environ()})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR:", err)
+		os.Exit(2)
+	}
+	profile, err := resolved.Config.Profile(*stream)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR:", err)
+		os.Exit(2)
+	}
+	root := filepath.Dir(*readme)
+	tasks, err := collectTasks(profile, *inPath, root)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR:", err)
+		os.Exit(2)
+	}
+
+	src, err := os.ReadFile(*readme)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR:", err)
+		os.Exit(2)
+	}
+	r := renderer{badgeDir: *badgeDir, darkDir: *darkDir, summary: *summaryName}
+	out, stale, err := rewrite(string(src), map[string]func() string{
+		"badges": func() string { return r.badges(tasks) },
+		"tasks":  func() string { return r.table(tasks) },
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %s: %v\n", *readme, err)
+		os.Exit(2)
+	}
+
+	if len(stale) == 0 {
+		fmt.Printf("%s is up to date\n", *readme)
+		return
+	}
+	if *check {
+		fmt.Fprintf(os.Stderr, "%s is out of date: %s; run go run ./cmd/readmesync\n", *readme, strings.Join(stale, ", "))
+		os.Exit(1)
+	}
+	if err := writeFile(*readme, []byte(out)); err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR:", err)
+		os.Exit(2)
+	}
+	fmt.Printf("%s: updated %s\n", *readme, strings.Join(stale, ", "))
+}
+
+// collectTasks lists the registry tasks of the stream, or without a
+// registry the tasks found in the results file.
+func collectTasks(profile config.Profile, inPath, root string) ([]Task, error) {
+	var tasks []Task
+	if len(profile.Tasks) > 0 {
+		for _, ct := range profile.Tasks {
+			t := Task{ID: ct.ID, Title: ct.Title, Dir: ct.Dir()}
+			if d, ok := profile.DeadlineFor(ct); ok {
+				t.Deadline = d.At.Format("02.01.06, 15:04")
+				if d.Title != "" {
+					t.Deadline = d.Title + ": " + t.Deadline
+				}
+			}
+			tasks = append(tasks, t)
+		}
+	} else {
+		b, err := os.ReadFile(inPath)
+		if err != nil {
+			return nil, fmt.Errorf("no task registry in config and no results: %w", err)
+		}
+		var m map[string]json.RawMessage
+		if err := json.Unmarshal(b, &m); err != nil {
+			return nil, fmt.Errorf("%s: %w", inPath, err)
+		}
+		for k := range m {
+			if id, ok := config.TaskID(k); ok && profile.HasTask(id) {
+				tasks = append(tasks, Task{ID: id, Dir: "tasks/task_" + id})
+			}
+		}
+		sort.Slice(tasks, func(i, j int) bool {
+			a, _ := strconv.Atoi(tasks[i].ID)
+			b, _ := strconv.Atoi(tasks[j].ID)
+			return a < b
+		})
+	}
+
+	for i := range tasks {
+		if tasks[i].Title == "" {
+			tasks[i].Title = readmeTitle(filepath.Join(root, filepath.FromSlash(tasks[i].Dir), "README.md"))
+		}
+		if tasks[i].Title == "" {
+			tasks[i].Title = "Задание " + tasks[i].ID
+		}
+	}
+	return tasks, nil
+}
+
+// readmeTitle returns the first heading of a task README.
+func readmeTitle(p string) string {
+	f, err := os.Open(p)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if strings.HasPrefix(line, "#") {
+			if h := strings.TrimSpace(strings.TrimLeft(line, "#")); h != "" {
+				return h
+			}
+		}
+	}
+	return ""
+}
+
+type renderer struct {
+	badgeDir string
+	darkDir  string
+	summary  string
+}
+
+// badge returns the image markup for a badge file, with a dark variant
+// for GitHub's dark theme when one is generated.
+func (r renderer) badge(alt, file string) string {
+	light := path.Join(r.badgeDir, file)
+	if r.darkDir == "" {
+		return fmt.Sprintf("![%s](%s)", alt, light)
+	}
+	return fmt.Sprintf(`<picture><source media="(prefers-color-scheme: dark)" srcset="%s"><img alt="%s" src="%s"></picture>`,
+		path.Join(r.darkDir, file), alt, light)
+}
+
+func (r renderer) badges(tasks []Task) string {
+	var b strings.Builder
+	if r.summary != "" {
+		b.WriteString(r.badge("tasks", r.summary) + "\n\n")
+	}
+	for _, t := range tasks {
+		b.WriteString(r.badge("task "+t.ID, "task_"+t.ID+".svg") + "\n")
+	}
+	return b.String()
+}
+
+func (r renderer) table(tasks []Task) string {
+	var b strings.Builder
+	b.WriteString("| № | Задание | Статус | Дедлайн |\n")
+	b.WriteString("|---|---|---|---|\n")
+	for _, t := range tasks {
+		deadline := t.Deadline
+		if deadline == "" {
+			deadline = "—"
+		}
+		fmt.Fprintf(&b, "| %s | [%s](%s/README.md) | %s | %s |\n",
+			t.ID, mdEscape(t.Title), t.Dir, r.badge("task "+t.ID, "task_"+t.ID+".svg"), mdEscape(deadline))
+	}
+	return b.String()
+}
+
+func mdEscape(s string) string {
+	return strings.NewReplacer("|", `\|`, "[", `\[`, "]", `\]`).Replace(s)
+}
+
+// writeFile replaces p via a temp file.
+func writeFile(p string, data []byte) error {
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, p)
+}
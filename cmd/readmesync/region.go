@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var markerRe = regexp.MustCompile(`(?m)^[ \t]*<!--\s*readmesync:(begin|end)\s+(\S+)\s*-->[ \t]*\r?$`)
+
+// rewrite replaces the body of every marked region with the output of its
+// generator and returns the new document and the names of regions whose
+// body changed. Unknown, unclosed or nested regions are errors; a document
+// without regions is one too, since there would be nothing to keep in sync.
+func rewrite(doc string, gens map[string]func() string) (string, []string, error) {
+	marks := markerRe.FindAllStringSubmatchIndex(doc, -1)
+	if len(marks) == 0 {
+		names := make([]string, 0, len(gens))
+		for n := range gens {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return "", nil, fmt.Errorf("no readmesync regions (add <!-- readmesync:begin NAME --> ... <!-- readmesync:end NAME --> for %s)", strings.Join(names, ", "))
+	}
+
+	var (
+		out   strings.Builder
+		stale []string
+		seen  = map[string]bool{}
+		last  int
+	)
+	for i := 0; i < len(marks); i += 2 {
+		begin := marks[i]
+		kind, name := doc[begin[2]:begin[3]], doc[begin[4]:begin[5]]
+		line := strings.Count(doc[:begin[0]], "\n") + 1
+		if kind != "begin" {
+			return "", nil, fmt.Errorf("line %d: readmesync:end %s without begin", line, name)
+		}
+		gen, ok := gens[name]
+		if !ok {
+			return "", nil, fmt.Errorf("line %d: unknown region %q", line, name)
+		}
+		if seen[name] {
+			return "", nil, fmt.Errorf("line %d: region %q appears twice", line, name)
+		}
+		seen[name] = true
+		if i+1 >= len(marks) {
+			return "", nil, fmt.Errorf("line %d: region %q is not closed", line, name)
+		}
+		end := marks[i+1]
+		if k, n := doc[end[2]:end[3]], doc[end[4]:end[5]]; k != "end" || n != name {
+			return "", nil, fmt.Errorf("line %d: region %q is not closed before %s %s", line, name, k, n)
+		}
+
+		// тело — строки между маркерами; маркеры занимают строку целиком,
+		// так что за begin всегда идёт перевод строки
+		bodyStart := begin[1] + 1
+		body := doc[bodyStart:end[0]]
+		fresh := gen()
+		if body != fresh {
+			stale = append(stale, name)
+		}
+		out.WriteString(doc[last:bodyStart])
+		out.WriteString(fresh)
+		last = end[0]
+	}
+	out.WriteString(doc[last:])
+	return out.String(), stale, nil
+}
@@ -0,0 +1,352 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"industry_backend_go/internal/history"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+const usage = `usage: reportdiff [flags] <base> <head>
+
+Compares two testreport results and lists what the head fixed, broke or
+made flaky. <base> and <head> are package-results.json files or history
+entries: "history:<commit>" (a SHA prefix) or "history:latest".
+
+exit codes: 0 no regressions, 1 something that passed in base does not pass
+in head, 2 usage or io error
+
+flags:
+`
+
+// Result is the part of a testreport package result reportdiff needs.
+type Result struct {
+	Status         string        `json:"status"`
+	State          string        `json:"state,omitempty"`
+	Classification string        `json:"classification,omitempty"`
+	Task           string        `json:"task,omitempty"`
+	FlakyTests     []string      `json:"flaky_tests,omitempty"`
+	Tests          []*TestResult `json:"tests,omitempty"`
+}
+
+// state is what the package counts as: testreport marks a package that
+// failed only on flaky tests as "flaky", so it is listed under newly flaky
+// and not also as broken. History entries keep the status only.
+func (r *Result) state() string {
+	if r.State != "" {
+		return r.State
+	}
+	return r.Status
+}
+
+type TestResult struct {
+	Name     string        `json:"name"`
+	Status   string        `json:"status"`
+	Subtests []*TestResult `json:"subtests,omitempty"`
+}
+
+func main() {
+	historyPath := flag.String("history", history.DefaultPath, "history log for history:<commit> arguments")
+	format := flag.String("format", "text", "output format: text, markdown or json")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	render, ok := renderers[*format]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown format %q (want text, markdown or json)\n", *format)
+		os.Exit(2)
+	}
+
+	base, err := load(flag.Arg(0), *historyPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR:", err)
+		os.Exit(2)
+	}
+	head, err := load(flag.Arg(1), *historyPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR:", err)
+		os.Exit(2)
+	}
+
+	d := compare(base, head)
+	d.Base, d.Head = flag.Arg(0), flag.Arg(1)
+	if err := render(os.Stdout, d); err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR:", err)
+		os.Exit(2)
+	}
+	if d.Regressed() {
+		os.Exit(1)
+	}
+}
+
+// load reads a package-results.json file or a history entry.
+func load(arg, historyPath string) (map[string]*Result, error) {
+	ref, ok := strings.CutPrefix(arg, "history:")
+	if !ok {
+		b, err := os.ReadFile(arg)
+		if err != nil {
+			return nil, err
+		}
+		var m map[string]*Result
+		if err := json.Unmarshal(b, &m); err != nil {
+			return nil, fmt.Errorf("%s: %w", arg, err)
+		}
+		return m, nil
+	}
+
+	runs, err := history.Load(historyPath)
+	if err != nil {
+		return nil, err
+	}
+	run, err := findRun(runs, ref)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", historyPath, err)
+	}
+	m := make(map[string]*Result, len(run.Packages))
+	for pkg, p := range run.Packages {
+		m[pkg] = &Result{Status: p.Status, Classification: p.Classification, Task: p.Task, FlakyTests: p.Flaky}
+	}
+	return m, nil
+}
+
+// findRun returns the latest run of the commit; "latest" means the last run
+// in the log.
+func findRun(runs []history.Run, ref string) (history.Run, error) {
+	if len(runs) == 0 {
+		return history.Run{}, fmt.Errorf("no runs recorded")
+	}
+	if ref == "latest" {
+		return runs[len(runs)-1], nil
+	}
+	var found []history.Run
+	commits := map[string]bool{}
+	for _, r := range runs {
+		if ref != "" && strings.HasPrefix(r.Commit, ref) {
+			found = append(found, r)
+			commits[r.Commit] = true
+		}
+	}
+	switch {
+	case len(found) == 0:
+		return history.Run{}, fmt.Errorf("no run of commit %q", ref)
+	case len(commits) > 1:
+		return history.Run{}, fmt.Errorf("commit prefix %q is ambiguous", ref)
+	}
+	return found[len(found)-1], nil
+}
+
+// Change is one package or test that differs between base and head.
+// Test is empty for package-level changes; Before/After are "" when the
+// package or test is missing on that side.
+type Change struct {
+	Package string `json:"package"`
+	Task    string `json:"task,omitempty"`
+	Test    string `json:"test,omitempty"`
+	Before  string `json:"before,omitempty"`
+	After   string `json:"after,omitempty"`
+}
+
+type Diff struct {
+	Base       string   `json:"base"`
+	Head       string   `json:"head"`
+	Broken     []Change `json:"broken"`      // passed in base, fails (or never finished) in head
+	Fixed      []Change `json:"fixed"`       // did not pass in base, passes in head
+	NewlyFlaky []Change `json:"newly_flaky"` // flaky in head, not in base
+	Added      []Change `json:"added"`
+	Removed    []Change `json:"removed"`
+}
+
+// Regressed reports whether anything that passed in base is broken.
+func (d *Diff) Regressed() bool { return len(d.Broken) > 0 }
+
+func compare(base, head map[string]*Result) *Diff {
+	d := &Diff{}
+	for _, pkg := range unionKeys(base, head) {
+		b, h := base[pkg], head[pkg]
+		switch {
+		case b == nil:
+			d.Added = append(d.Added, Change{Package: pkg, Task: h.Task, After: h.state()})
+			continue
+		case h == nil:
+			d.Removed = append(d.Removed, Change{Package: pkg, Task: b.Task, Before: b.state()})
+			continue
+		}
+		task := h.Task
+		if task == "" {
+			task = b.Task
+		}
+		d.classify(Change{Package: pkg, Task: task, Before: b.state(), After: h.state()})
+
+		bt, ht := testStatuses(b.Tests), testStatuses(h.Tests)
+		wasFlaky := map[string]bool{}
+		for _, name := range b.FlakyTests {
+			wasFlaky[name] = true
+		}
+		for _, name := range h.FlakyTests {
+			if !wasFlaky[name] {
+				d.NewlyFlaky = append(d.NewlyFlaky, Change{Package: pkg, Task: task, Test: name, Before: bt[name], After: "flaky"})
+			}
+		}
+
+		// истории хранят только статус пакета: тесты сравниваем, если они есть с обеих сторон
+		if len(b.Tests) == 0 || len(h.Tests) == 0 {
+			continue
+		}
+		for _, name := range unionKeys(bt, ht) {
+			c := Change{Package: pkg, Task: task, Test: name, Before: bt[name], After: ht[name]}
+			switch {
+			case c.Before == "":
+				d.Added = append(d.Added, c)
+			case c.After == "":
+				d.Removed = append(d.Removed, c)
+			default:
+				d.classify(c)
+			}
+		}
+	}
+	return d
+}
+
+func (d *Diff) classify(c Change) {
+	switch {
+	case c.Before == "pass" && (c.After == "fail" || c.After == "unknown"):
+		d.Broken = append(d.Broken, c)
+	case c.Before != "pass" && c.After == "pass":
+		d.Fixed = append(d.Fixed, c)
+	}
+}
+
+// testStatuses flattens a test tree into full name → status.
+func testStatuses(ts []*TestResult) map[string]string {
+	m := map[string]string{}
+	var walk func([]*TestResult)
+	walk = func(ts []*TestResult) {
+		for _, t := range ts {
+			m[t.Name] = t.Status
+			walk(t.Subtests)
+		}
+	}
+	walk(ts)
+	return m
+}
+
+func unionKeys[V any](a, b map[string]V) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var renderers = map[string]func(io.Writer, *Diff) error{
+	"text":     renderText,
+	"markdown": renderMarkdown,
+	"json":     renderJSON,
+}
+
+type section struct {
+	title   string
+	changes []Change
+}
+
+func (d *Diff) sections() []section {
+	return []section{
+		{"Broken", d.Broken},
+		{"Fixed", d.Fixed},
+		{"Newly flaky", d.NewlyFlaky},
+		{"Added", d.Added},
+		{"Removed", d.Removed},
+	}
+}
+
+func (c Change) name() string {
+	name := c.Package
+	if c.Task != "" {
+		name = "task " + c.Task
+	}
+	if c.Test != "" {
+		name += " " + c.Test
+	}
+	return name
+}
+
+func (c Change) transition() string {
+	switch {
+	case c.Before == "":
+		return c.After
+	case c.After == "":
+		return c.Before
+	}
+	return c.Before + " → " + c.After
+}
+
+func renderText(w io.Writer, d *Diff) error {
+	fmt.Fprintf(w, "base: %s\nhead: %s\n", d.Base, d.Head)
+	for _, s := range d.sections() {
+		if len(s.changes) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s (%d):\n", s.title, len(s.changes))
+		for _, c := range s.changes {
+			fmt.Fprintf(w, "  %-40s %s\n", c.name(), c.transition())
+		}
+	}
+	_, err := fmt.Fprintf(w, "\n%s\n", d.summary())
+	return err
+}
+
+func renderMarkdown(w io.Writer, d *Diff) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## Test changes\n\n`%s` → `%s`: %s\n", d.Base, d.Head, d.summary())
+	for _, s := range d.sections() {
+		if len(s.changes) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n### %s (%d)\n\n| Task | Test | Change |\n|---|---|---|\n", s.title, len(s.changes))
+		for _, c := range s.changes {
+			task := "`" + c.Package + "`"
+			if c.Task != "" {
+				task = "task " + c.Task
+			}
+			test := ""
+			if c.Test != "" {
+				test = "`" + strings.ReplaceAll(c.Test, "|", `\|`) + "`"
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s |\n", task, test, c.transition())
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func renderJSON(w io.Writer, d *Diff) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(d)
+}
+
+func (d *Diff) summary() string {
+	s := fmt.Sprintf("%d broken, %d fixed, %d newly flaky, %d added, %d removed",
+		len(d.Broken), len(d.Fixed), len(d.NewlyFlaky), len(d.Added), len(d.Removed))
+	if d.Regressed() {
+		return "REGRESSION: " + s
+	}
+	return s
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"industry_backend_go/internal/history"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const usage = `usage: reporthistory <command> [flags]
+
+commands:
+  summary      latest status, first green commit and last regression per task
+  timeline     status of each task at every recorded commit
+  regressions  every commit that broke a previously passing task
+
+flags (all commands):
+  -file <path>  history log written by testreport -history (default ` + history.DefaultPath + `)
+  -task <id>    only this task (id from the config, e.g. 03) or package path
+  -json         print JSON instead of a table
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	cmd, args := os.Args[1], os.Args[2:]
+	switch cmd {
+	case "summary", "timeline", "regressions":
+	case "-h", "--help", "help":
+		fmt.Print(usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", cmd, usage)
+		os.Exit(2)
+	}
+
+	fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+	file := fs.String("file", history.DefaultPath, "history log")
+	task := fs.String("task", "", "only this task id or package")
+	asJSON := fs.Bool("json", false, "print JSON")
+	_ = fs.Parse(args)
+
+	runs, err := history.Load(*file)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR:", err)
+		os.Exit(2)
+	}
+	if len(runs) == 0 {
+		fmt.Fprintf(os.Stderr, "no runs recorded in %s\n", *file)
+		os.Exit(1)
+	}
+
+	var tls []history.Timeline
+	for _, tl := range history.Timelines(runs) {
+		if *task == "" || tl.Task == *task || tl.Package == *task {
+			tls = append(tls, tl)
+		}
+	}
+
+	switch {
+	case *asJSON:
+		err = printJSON(os.Stdout, cmd, tls)
+	case cmd == "summary":
+		err = printSummary(os.Stdout, tls)
+	case cmd == "timeline":
+		err = printTimeline(os.Stdout, tls)
+	case cmd == "regressions":
+		err = printRegressions(os.Stdout, tls)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR:", err)
+		os.Exit(2)
+	}
+}
+
+func printJSON(w io.Writer, cmd string, tls []history.Timeline) error {
+	var v any = tls
+	if cmd == "regressions" {
+		type regression struct {
+			Package string `json:"package"`
+			Task    string `json:"task,omitempty"`
+			history.Regression
+		}
+		regs := []regression{}
+		for _, tl := range tls {
+			for _, r := range tl.Regressions {
+				regs = append(regs, regression{Package: tl.Package, Task: tl.Task, Regression: r})
+			}
+		}
+		v = regs
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func printSummary(w io.Writer, tls []history.Timeline) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "TASK\tSTATUS\tRUNS\tFIRST PASS\tLAST REGRESSION")
+	for _, tl := range tls {
+		latest := tl.Latest()
+		firstPass := "-"
+		if tl.FirstPass != nil {
+			firstPass = describe(*tl.FirstPass)
+		}
+		lastReg := "-"
+		if n := len(tl.Regressions); n > 0 {
+			lastReg = describe(tl.Regressions[n-1].Broken)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\n", name(tl), status(latest), len(tl.Points), firstPass, lastReg)
+	}
+	return tw.Flush()
+}
+
+func printTimeline(w io.Writer, tls []history.Timeline) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for i, tl := range tls {
+		if i > 0 {
+			fmt.Fprintln(tw)
+		}
+		fmt.Fprintln(tw, name(tl))
+		for _, p := range tl.Points {
+			tests := "-"
+			if p.Total > 0 {
+				tests = fmt.Sprintf("%d/%d", p.Passed, p.Total)
+			}
+			fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\n", day(p.When), short(p.Commit), status(p), tests)
+		}
+	}
+	return tw.Flush()
+}
+
+func printRegressions(w io.Writer, tls []history.Timeline) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "TASK\tLAST PASS\tBROKEN BY\tAS")
+	for _, tl := range tls {
+		for _, r := range tl.Regressions {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", name(tl), describe(r.LastPass), describe(r.Broken), status(r.Broken))
+		}
+	}
+	return tw.Flush()
+}
+
+func name(tl history.Timeline) string {
+	if tl.Task != "" {
+		return "task " + tl.Task
+	}
+	return tl.Package
+}
+
+func status(p history.Point) string {
+	if p.Classification != "" && p.Classification != "pass" {
+		return p.Status + " (" + p.Classification + ")"
+	}
+	return p.Status
+}
+
+func describe(p history.Point) string {
+	return day(p.When) + " " + short(p.Commit)
+}
+
+func day(t time.Time) string {
+	return t.Format("2006-01-02 15:04")
+}
+
+func short(sha string) string {
+	if len(sha) > 7 && !strings.ContainsAny(sha, "/.") {
+		return sha[:7]
+	}
+	return sha
+}
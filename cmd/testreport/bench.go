@@ -0,0 +1,164 @@
+package main
+
+import (
+	"industry_backend_go/internal/config"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// BenchmarkResult aggregates the -count runs of one benchmark; values are
+// medians over the runs.
+type BenchmarkResult struct {
+	Name        string   `json:"name"` // without the -GOMAXPROCS suffix
+	Runs        int      `json:"runs"`
+	NsPerOp     float64  `json:"ns_per_op"`
+	BytesPerOp  *int64   `json:"bytes_per_op,omitempty"` // only with -benchmem
+	AllocsPerOp *int64   `json:"allocs_per_op,omitempty"`
+	Status      string   `json:"status,omitempty"` // pass|fail|missing, only for benchmarks with configured limits
+	Violations  []string `json:"violations,omitempty"`
+}
+
+type benchSample struct {
+	nsPerOp     float64
+	bytesPerOp  *int64
+	allocsPerOp *int64
+}
+
+var (
+	// "BenchmarkGet-8   \t 1000000\t  1052 ns/op\t  16 B/op\t  1 allocs/op"
+	benchLineRe  = regexp.MustCompile(`^(Benchmark\S*)\s+(\d+)\s+(.+)$`)
+	benchProcsRe = regexp.MustCompile(`-\d+$`)
+)
+
+// scanBenchOutput feeds raw output into the benchmark line parser. go test
+// may split one result line over several output events, so an unfinished
+// line is kept until its newline arrives.
+func (r *PackageResult) scanBenchOutput(out string) {
+	r.benchPartial += out
+	for {
+		i := strings.IndexByte(r.benchPartial, '\n')
+		if i < 0 {
+			return
+		}
+		line := r.benchPartial[:i]
+		r.benchPartial = r.benchPartial[i+1:]
+		if name, s, ok := parseBenchLine(line); ok {
+			if _, seen := r.benchSamples[name]; !seen {
+				if r.benchSamples == nil {
+					r.benchSamples = map[string][]benchSample{}
+				}
+				r.benchOrder = append(r.benchOrder, name)
+			}
+			r.benchSamples[name] = append(r.benchSamples[name], s)
+		}
+	}
+}
+
+func parseBenchLine(line string) (string, benchSample, bool) {
+	m := benchLineRe.FindStringSubmatch(strings.TrimSpace(line))
+	if m == nil {
+		return "", benchSample{}, false
+	}
+	var s benchSample
+	seenNs := false
+	fields := strings.Fields(m[3])
+	for i := 0; i+1 < len(fields); i += 2 {
+		v, unit := fields[i], fields[i+1]
+		switch unit {
+		case "ns/op":
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return "", benchSample{}, false
+			}
+			s.nsPerOp, seenNs = f, true
+		case "B/op":
+			if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+				s.bytesPerOp = &n
+			}
+		case "allocs/op":
+			if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+				s.allocsPerOp = &n
+			}
+		}
+	}
+	if !seenNs {
+		return "", benchSample{}, false
+	}
+	return benchProcsRe.ReplaceAllString(m[1], ""), s, true
+}
+
+// finalizeBenchmarks builds Benchmarks from the samples and checks them
+// against the task limits; a limit without any run counts as missing.
+func (r *PackageResult) finalizeBenchmarks(limits []config.BenchmarkLimit) {
+	r.Benchmarks = nil
+	r.Performance = ""
+	for _, name := range r.benchOrder {
+		r.Benchmarks = append(r.Benchmarks, summarize(name, r.benchSamples[name]))
+	}
+	if len(limits) == 0 {
+		return
+	}
+
+	r.Performance = "pass"
+	for _, l := range limits {
+		i := -1
+		for j := range r.Benchmarks {
+			if r.Benchmarks[j].Name == l.Name {
+				i = j
+				break
+			}
+		}
+		if i < 0 {
+			r.Benchmarks = append(r.Benchmarks, BenchmarkResult{Name: l.Name, Status: "missing"})
+			r.Performance = "fail"
+			continue
+		}
+		b := &r.Benchmarks[i]
+		b.Violations = l.Check(b.NsPerOp, b.BytesPerOp, b.AllocsPerOp)
+		b.Status = "pass"
+		if len(b.Violations) > 0 {
+			b.Status = "fail"
+			r.Performance = "fail"
+		}
+	}
+}
+
+func summarize(name string, samples []benchSample) BenchmarkResult {
+	b := BenchmarkResult{Name: name, Runs: len(samples)}
+	ns := make([]float64, 0, len(samples))
+	var bytes, allocs []float64
+	for _, s := range samples {
+		ns = append(ns, s.nsPerOp)
+		if s.bytesPerOp != nil {
+			bytes = append(bytes, float64(*s.bytesPerOp))
+		}
+		if s.allocsPerOp != nil {
+			allocs = append(allocs, float64(*s.allocsPerOp))
+		}
+	}
+	b.NsPerOp = median(ns)
+	if len(bytes) == len(samples) {
+		v := int64(median(bytes))
+		b.BytesPerOp = &v
+	}
+	if len(allocs) == len(samples) {
+		v := int64(median(allocs))
+		b.AllocsPerOp = &v
+	}
+	return b
+}
+
+func median(xs []float64) float64 {
+	if len(xs) == 0 {
+		return 0
+	}
+	s := append([]float64(nil), xs...)
+	sort.Float64s(s)
+	n := len(s)
+	if n%2 == 1 {
+		return s[n/2]
+	}
+	return (s[n/2-1] + s[n/2]) / 2
+}
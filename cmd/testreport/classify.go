@@ -0,0 +1,113 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Diagnostic is one compiler (or vet) message from a failed build, e.g.
+// "tasks/task_00/solution.go:5:1: missing return".
+type Diagnostic struct {
+	File    string `json:"file"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	s := d.File
+	if d.Line > 0 {
+		s += ":" + strconv.Itoa(d.Line)
+	}
+	return s + ": " + d.Message
+}
+
+var (
+	diagnosticRe = regexp.MustCompile(`^(\S+\.go):(\d+)(?::(\d+))?: (.+)$`)
+	// buildFailedRe matches the summary line go test prints for a package
+	// that did not compile, e.g. "FAIL\tmod/pkg [build failed]".
+	buildFailedRe = regexp.MustCompile(`(?m)^FAIL\s+\S+ \[(?:build|setup) failed\]$`)
+)
+
+// parseDiagnostics reads "file:line[:col]: message" lines of build output.
+// Indented lines continue the previous message (e.g. "have (...) want
+// (...)"); "# pkg" headers and other lines are skipped.
+func parseDiagnostics(out string) []Diagnostic {
+	var ds []Diagnostic
+	for _, l := range strings.Split(out, "\n") {
+		l = strings.TrimRight(l, " \r")
+		if m := diagnosticRe.FindStringSubmatch(l); m != nil {
+			d := Diagnostic{File: strings.TrimPrefix(m[1], "./"), Message: m[4]}
+			d.Line, _ = strconv.Atoi(m[2])
+			d.Column, _ = strconv.Atoi(m[3])
+			ds = append(ds, d)
+			continue
+		}
+		if len(ds) > 0 && (strings.HasPrefix(l, "\t") || strings.HasPrefix(l, "  ")) {
+			ds[len(ds)-1].Message += "\n" + strings.TrimSpace(l)
+		}
+	}
+	return ds
+}
+
+// recordBuildFailure attaches compiler output to a package whose fail event
+// names a FailedBuild. Older go versions print build errors to stderr only
+// and are caught by buildFailedRe in finalizeTests instead.
+func (r *PackageResult) recordBuildFailure(buildOutput string) {
+	r.buildFailed = true
+	r.BuildErrors = parseDiagnostics(buildOutput)
+	r.buildOutput = buildOutput
+}
+
+// classify explains why a package failed: build_error, timeout, panic,
+// race or test_failure; "pass" for passed packages. Skipped and never run
+// packages stay unclassified.
+func (r *PackageResult) classify() string {
+	switch r.Status {
+	case "pass":
+		return "pass"
+	case "fail":
+	default:
+		return ""
+	}
+	if r.buildFailed {
+		return "build_error"
+	}
+
+	panics := []*PanicReport{r.Panic}
+	races := len(r.Races) > 0
+	for _, t := range flattenTests(r.Tests) {
+		panics = append(panics, t.Panic)
+		races = races || len(t.Races) > 0
+	}
+	hasPanic := false
+	for _, p := range panics {
+		if p == nil {
+			continue
+		}
+		if strings.HasPrefix(p.Message, "test timed out") {
+			return "timeout"
+		}
+		hasPanic = true
+	}
+	switch {
+	case hasPanic:
+		return "panic"
+	case races:
+		return "race"
+	default:
+		return "test_failure"
+	}
+}
+
+// state is Status with flaky packages told apart. go test fails a package
+// if any -count repetition failed, so a package whose failed tests all
+// passed on other runs comes as "fail" and is "flaky" here.
+func (r *PackageResult) state() string {
+	if r.Counts.Flaky > 0 && (r.Status == "pass" ||
+		r.Status == "fail" && r.Counts.Failed == 0 && r.Classification == "test_failure") {
+		return "flaky"
+	}
+	return r.Status
+}
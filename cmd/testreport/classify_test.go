@@ -0,0 +1,130 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	// вывод go 1.27 для вызова с недостающим аргументом
+	out := "# fx2/bad\n" +
+		"bad/bad.go:6:13: not enough arguments in call to add\n" +
+		"\thave (number)\n" +
+		"\twant (int, int)\n" +
+		"./bad/other.go:3: undefined: x\n"
+	want := []Diagnostic{
+		{File: "bad/bad.go", Line: 6, Column: 13, Message: "not enough arguments in call to add\nhave (number)\nwant (int, int)"},
+		{File: "bad/other.go", Line: 3, Message: "undefined: x"},
+	}
+	if got := parseDiagnostics(out); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseDiagnostics = %+v, want %+v", got, want)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name, file, pkg string
+		want            string
+		buildErrors     []Diagnostic
+		panic           string // префикс Panic.Message у теста или пакета
+	}{
+		{
+			// go 1.24+: build-output события и FailedBuild у fail пакета
+			name: "failed build", file: "build.jsonl", pkg: "fx/broken",
+			want:        "build_error",
+			buildErrors: []Diagnostic{{File: "broken/broken.go", Line: 7, Column: 1, Message: "missing return"}},
+		},
+		{
+			// до go 1.24 (GODEBUG=gotestjsonbuildtext=1): компилятор пишет в
+			// stderr, в JSON только строка "FAIL pkg [build failed]"
+			name: "build failed line", file: "build_text.jsonl", pkg: "fx/broken",
+			want: "build_error",
+		},
+		{
+			name: "timeout", file: "timeout.jsonl", pkg: "fx/timeout",
+			want:  "timeout",
+			panic: "test timed out after 1s",
+		},
+		{
+			name: "panic", file: "panic.jsonl", pkg: "fx/panicky",
+			want:  "panic",
+			panic: "runtime error: index out of range",
+		},
+		{name: "race", file: "race.jsonl", pkg: "fx/racy", want: "race"},
+	}
+	for _, tt := range tests {
+		res := collectFile(t, tt.file)[tt.pkg]
+		if res == nil {
+			t.Fatalf("%s: no result for %s", tt.name, tt.pkg)
+		}
+		if res.Status != "fail" || res.Classification != tt.want {
+			t.Errorf("%s: status %q, classification %q, want fail, %q", tt.name, res.Status, res.Classification, tt.want)
+		}
+		if !reflect.DeepEqual(res.BuildErrors, tt.buildErrors) {
+			t.Errorf("%s: BuildErrors = %+v, want %+v", tt.name, res.BuildErrors, tt.buildErrors)
+		}
+		if tt.want == "build_error" && res.Output == "" {
+			t.Errorf("%s: no output to explain the build failure", tt.name)
+		}
+		if tt.panic != "" {
+			p := res.Panic
+			for _, tr := range flattenTests(res.Tests) {
+				if p == nil {
+					p = tr.Panic
+				}
+			}
+			if p == nil || !strings.HasPrefix(p.Message, tt.panic) {
+				t.Errorf("%s: panic = %+v, want %q...", tt.name, p, tt.panic)
+			}
+		}
+	}
+}
+
+func TestClassify_Timeout(t *testing.T) {
+	t.Parallel()
+
+	// тест, на котором сработал -timeout, не получает ни pass, ни fail
+	res := collectFile(t, "timeout.jsonl")["fx/timeout"]
+	if res == nil || len(res.Tests) != 1 {
+		t.Fatalf("results = %+v", res)
+	}
+	slow := res.Tests[0]
+	if slow.Status != "unknown" || slow.Panic == nil {
+		t.Fatalf("TestSlow = %+v", slow)
+	}
+	if want := "test timed out after 1s\nrunning tests:\nTestSlow (1s)"; slow.Panic.Message != want {
+		t.Errorf("Panic.Message = %q, want %q", slow.Panic.Message, want)
+	}
+	if want := "/tmp/fx/timeout/timeout_test.go:9"; slow.Panic.Location != want {
+		t.Errorf("Panic.Location = %q, want %q", slow.Panic.Location, want)
+	}
+}
+
+func TestState(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		file, pkg string
+		want      string
+	}{
+		// -count=2: TestFlaky падает на втором повторе, go test роняет пакет
+		{"flaky.jsonl", "fx/flaky", "flaky"},
+		// гонка валит тест один раз на бинарь — это не нестабильность
+		{"race.jsonl", "fx/racy", "fail"},
+		{"panic.jsonl", "fx/panicky", "fail"},
+	}
+	for _, tt := range tests {
+		res := collectFile(t, tt.file)[tt.pkg]
+		if res == nil {
+			t.Fatalf("%s: no result for %s", tt.file, tt.pkg)
+		}
+		if res.State != tt.want {
+			t.Errorf("%s: State = %q (status %q, counts %+v), want %q", tt.file, res.State, res.Status, res.Counts, tt.want)
+		}
+	}
+}
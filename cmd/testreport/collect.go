@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"industry_backend_go/internal/config"
+	"os"
+	"strings"
+	"time"
+)
+
+// collector folds go test -json events into per-package results.
+type collector struct {
+	profile    config.Profile
+	commitTime time.Time
+	maxOutput  int
+	results    map[string]*PackageResult
+
+	// вывод компилятора по ImportPath, например "mod/pkg [mod/pkg.test]"
+	buildOutputs map[string]*strings.Builder
+}
+
+func newCollector(profile config.Profile, commitTime time.Time, maxOutput int) *collector {
+	return &collector{
+		profile:      profile,
+		commitTime:   commitTime,
+		maxOutput:    maxOutput,
+		results:      map[string]*PackageResult{},
+		buildOutputs: map[string]*strings.Builder{},
+	}
+}
+
+func (c *collector) ensure(pkg string) {
+	if pkg == "" {
+		return
+	}
+	// игнорируемые пакеты и задания вне реестра/потока в отчёт не попадают
+	if !c.profile.Reports(pkg) {
+		return
+	}
+	if _, ok := c.results[pkg]; !ok {
+		c.results[pkg] = &PackageResult{Status: "unknown", maxOutput: c.maxOutput}
+	}
+}
+
+// addLine parses one line of go test -json output. It returns the package
+// whose final pass/fail/skip event the line was, if any.
+func (c *collector) addLine(line string) (finished string) {
+	line = strings.TrimSpace(line)
+	if line == "" || !strings.HasPrefix(line, "{") {
+		return ""
+	}
+	var ev TestEvent
+	if err := json.Unmarshal([]byte(line), &ev); err != nil {
+		// ignore non-json garbage lines
+		return ""
+	}
+	return c.add(ev)
+}
+
+func (c *collector) add(ev TestEvent) (finished string) {
+	if ev.Action == "build-output" {
+		b, ok := c.buildOutputs[ev.ImportPath]
+		if !ok {
+			b = &strings.Builder{}
+			c.buildOutputs[ev.ImportPath] = b
+		}
+		b.WriteString(ev.Output)
+		return ""
+	}
+	if ev.Package == "" {
+		return ""
+	}
+	c.ensure(ev.Package)
+	res, ok := c.results[ev.Package]
+	if !ok {
+		// ignored package or not a task of the stream
+		return ""
+	}
+
+	if ev.Action == "output" {
+		res.scanBenchOutput(ev.Output)
+	}
+
+	// package-level result: Action pass/fail/skip and empty Test
+	if ev.Test == "" {
+		switch ev.Action {
+		case "output":
+			res.recordTest(ev)
+		case "pass":
+			res.Status = "pass"
+			res.Elapsed = ev.Elapsed
+			return ev.Package
+		case "fail":
+			res.Status = "fail"
+			res.Elapsed = ev.Elapsed
+			if ev.FailedBuild != "" {
+				var out string
+				if b, ok := c.buildOutputs[ev.FailedBuild]; ok {
+					out = b.String()
+				}
+				res.recordBuildFailure(out)
+			}
+			return ev.Package
+		case "skip":
+			// sometimes packages get skipped; keep it explicit
+			if res.Status == "unknown" {
+				res.Status = "skip"
+			}
+			return ev.Package
+		}
+		return ""
+	}
+
+	res.recordTest(ev)
+
+	// test-level fail: Action fail and Test present; with -count each
+	// test is listed once
+	if ev.Action == "fail" && res.test(ev.Test).Failed == 1 {
+		res.FailedTests = append(res.FailedTests, ev.Test)
+	}
+	return ""
+}
+
+// finalize derives statuses, counts and grades. With final == false it
+// can be called repeatedly on a live stream: tests that are still running
+// keep their output buffers and are not treated as hung.
+func (c *collector) finalize(final bool) {
+	for pkg, res := range c.results {
+		res.finalizeTests(final)
+		var limits []config.BenchmarkLimit
+		if t, ok := c.profile.TaskForPackage(pkg); ok {
+			limits = t.Benchmarks
+		}
+		res.finalizeBenchmarks(limits)
+	}
+	grade(c.results, c.profile, c.commitTime)
+}
+
+// addBenchFile reads benchmark results from a separate go test -bench -json
+// run. Only output events are used: pass/fail of that run says nothing
+// about the tests.
+func (c *collector) addBenchFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	sc := newScanner(f)
+	for sc.Scan() {
+		var ev TestEvent
+		if err := json.Unmarshal(sc.Bytes(), &ev); err != nil || ev.Action != "output" {
+			continue
+		}
+		c.ensure(ev.Package)
+		if res, ok := c.results[ev.Package]; ok {
+			res.scanBenchOutput(ev.Output)
+		}
+	}
+	return sc.Err()
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Coverage is the statement coverage of one package from -coverprofile.
+type Coverage struct {
+	Statements int            `json:"statements"`
+	Covered    int            `json:"covered"`
+	Percent    float64        `json:"percent"`
+	Files      []FileCoverage `json:"files,omitempty"` // editable files of registry tasks only
+}
+
+// FileCoverage lists the lines of one file no test reached.
+type FileCoverage struct {
+	File       string      `json:"file"` // repo-relative
+	Statements int         `json:"statements"`
+	Covered    int         `json:"covered"`
+	Percent    float64     `json:"percent"`
+	Uncovered  []LineRange `json:"uncovered,omitempty"`
+}
+
+type LineRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+func (r LineRange) String() string {
+	if r.Start == r.End {
+		return strconv.Itoa(r.Start)
+	}
+	return strconv.Itoa(r.Start) + "-" + strconv.Itoa(r.End)
+}
+
+// coverBlock is one line of a cover profile:
+// "mod/pkg/file.go:startLine.startCol,endLine.endCol numStmts count".
+type coverBlock struct {
+	startLine, startCol int
+	endLine, endCol     int
+	stmts               int
+	count               int
+}
+
+// coverProfile maps import-path file names ("mod/pkg/solution.go") to
+// their blocks. A block listed several times (merged profiles, -coverpkg)
+// is kept once with the counts summed.
+type coverProfile map[string][]coverBlock
+
+func loadCoverProfile(p string) (coverProfile, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parseCoverProfile(f)
+}
+
+func parseCoverProfile(r io.Reader) (coverProfile, error) {
+	type key struct {
+		file                string
+		startLine, startCol int
+		endLine, endCol     int
+	}
+	merged := map[key]*coverBlock{}
+	var order []key
+
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "mode:") {
+			continue
+		}
+		var b coverBlock
+		i := strings.LastIndex(line, ".go:")
+		if i < 0 {
+			return nil, fmt.Errorf("cover profile line %d: %q", n, line)
+		}
+		file := line[:i+3]
+		if _, err := fmt.Sscanf(line[i+4:], "%d.%d,%d.%d %d %d",
+			&b.startLine, &b.startCol, &b.endLine, &b.endCol, &b.stmts, &b.count); err != nil {
+			return nil, fmt.Errorf("cover profile line %d: %v", n, err)
+		}
+		k := key{file, b.startLine, b.startCol, b.endLine, b.endCol}
+		if m, ok := merged[k]; ok {
+			m.count += b.count
+			continue
+		}
+		merged[k] = &b
+		order = append(order, k)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	prof := coverProfile{}
+	for _, k := range order {
+		prof[k.file] = append(prof[k.file], *merged[k])
+	}
+	return prof, nil
+}
+
+// attachCoverage fills Coverage of every reported package that has
+// files in the profile.
+func (rep *Report) attachCoverage(prof coverProfile) {
+	files := make([]string, 0, len(prof))
+	for f := range prof {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+
+	for pkg, res := range rep.Packages {
+		var cov Coverage
+		var editable []string
+		task, isTask := rep.Profile.TaskForPackage(pkg)
+		if isTask {
+			editable = task.EditableFiles()
+		}
+		for _, f := range files {
+			if path.Dir(f) != pkg {
+				continue
+			}
+			fc := fileCoverage(prof[f])
+			cov.Statements += fc.Statements
+			cov.Covered += fc.Covered
+			for _, e := range editable {
+				if e == joinDir(task.Dir(), path.Base(f)) {
+					fc.File = e
+					cov.Files = append(cov.Files, fc)
+				}
+			}
+		}
+		if cov.Statements == 0 {
+			continue
+		}
+		cov.Percent = percent(cov.Covered, cov.Statements)
+		res.Coverage = &cov
+	}
+}
+
+// fileCoverage counts statements and collects the lines only uncovered
+// blocks touch. A line shared with a covered block (e.g. "if x {" before
+// an untaken branch) counts as covered.
+func fileCoverage(blocks []coverBlock) FileCoverage {
+	var fc FileCoverage
+	covered, uncovered := map[int]bool{}, map[int]bool{}
+	for _, b := range blocks {
+		fc.Statements += b.stmts
+		lines := covered
+		if b.count > 0 {
+			fc.Covered += b.stmts
+		} else {
+			lines = uncovered
+		}
+		if b.stmts == 0 {
+			continue
+		}
+		end := b.endLine
+		if end > b.startLine && b.endCol <= 1 {
+			// блок кончается в начале строки: сама строка в него не входит
+			end--
+		}
+		for l := b.startLine; l <= end; l++ {
+			lines[l] = true
+		}
+	}
+	fc.Percent = percent(fc.Covered, fc.Statements)
+
+	var ls []int
+	for l := range uncovered {
+		if !covered[l] {
+			ls = append(ls, l)
+		}
+	}
+	sort.Ints(ls)
+	for _, l := range ls {
+		if n := len(fc.Uncovered); n > 0 && fc.Uncovered[n-1].End == l-1 {
+			fc.Uncovered[n-1].End = l
+			continue
+		}
+		fc.Uncovered = append(fc.Uncovered, LineRange{Start: l, End: l})
+	}
+	return fc
+}
+
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(n)/float64(total)*1000) / 10
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+)
+
+// FlakyTest is one entry of the optional flaky summary.
+type FlakyTest struct {
+	Package string  `json:"package"`
+	Test    string  `json:"test"`
+	Passed  int     `json:"passed"`
+	Failed  int     `json:"failed"`
+	Ratio   float64 `json:"ratio"`
+}
+
+// flakySummary lists flaky tests of all packages, worst ratio first.
+func flakySummary(results map[string]*PackageResult) []FlakyTest {
+	out := []FlakyTest{}
+	for pkg, res := range results {
+		for _, name := range res.FlakyTests {
+			t := res.testIndex[name]
+			out = append(out, FlakyTest{
+				Package: pkg,
+				Test:    name,
+				Passed:  t.Passed,
+				Failed:  t.Failed,
+				Ratio:   t.FlakyRatio,
+			})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Ratio != out[j].Ratio {
+			return out[i].Ratio > out[j].Ratio
+		}
+		if out[i].Package != out[j].Package {
+			return out[i].Package < out[j].Package
+		}
+		return out[i].Test < out[j].Test
+	})
+	return out
+}
+
+func writeFlakySummary(path string, flaky []FlakyTest) error {
+	b, err := json.MarshalIndent(flaky, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0o644)
+}
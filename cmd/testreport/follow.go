@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+var errInterrupted = errors.New("interrupted")
+
+// newScanner reads go test -json lines; panic stack traces and long logs
+// make for large lines.
+func newScanner(r io.Reader) *bufio.Scanner {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 1024), 10*1024*1024)
+	return sc
+}
+
+// follow consumes events as go test emits them. The report is rewritten
+// every interval while new events arrive, and once more with final results
+// at EOF or on SIGINT/SIGTERM, so a killed CI job still leaves the results
+// of the packages that finished.
+func follow(in io.Reader, col *collector, rep *Report, interval time.Duration, progress io.Writer, write func(final bool) error) error {
+	lines := make(chan string)
+	scanErr := make(chan error, 1)
+	go func() {
+		sc := newScanner(in)
+		for sc.Scan() {
+			lines <- sc.Text()
+		}
+		scanErr <- sc.Err()
+		close(lines)
+	}()
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sig)
+
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+
+	dirty := false
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				if err := write(true); err != nil {
+					return err
+				}
+				return <-scanErr
+			}
+			dirty = true
+			if pkg := col.addLine(line); pkg != "" && progress != nil {
+				rep.printProgress(progress, pkg)
+			}
+		case <-tick.C:
+			if !dirty {
+				continue
+			}
+			dirty = false
+			if err := write(false); err != nil {
+				return err
+			}
+		case <-sig:
+			if err := write(true); err != nil {
+				return err
+			}
+			return errInterrupted
+		}
+	}
+}
+
+// printProgress prints one line for a package that just finished, e.g.
+// "[ 3/11] fail  tasks/task_04 (build_error) 0/0 tests 0.00s".
+func (rep *Report) printProgress(w io.Writer, pkg string) {
+	res := rep.Packages[pkg]
+	res.finalizeTests(false)
+
+	done := 0
+	for _, r := range rep.Packages {
+		if r.Status != "unknown" {
+			done++
+		}
+	}
+	width := len(fmt.Sprint(len(rep.Packages)))
+
+	line := fmt.Sprintf("[%*d/%d] %-5s %s", width, done, len(rep.Packages), res.Status, rep.packageDir(pkg))
+	if res.Classification != "" && res.Classification != "pass" {
+		line += " (" + res.Classification + ")"
+	}
+	if len(res.FlakyTests) > 0 {
+		line += fmt.Sprintf(" flaky=%d", len(res.FlakyTests))
+	}
+	fmt.Fprintf(w, "%s %d/%d tests %.2fs\n", line, res.Counts.Passed, res.Counts.Total, res.Elapsed)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"industry_backend_go/internal/config"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseCommitTime accepts RFC 3339 (git log --format=%cI) or unix seconds
+// (git log --format=%ct).
+func parseCommitTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if sec, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(sec, 0).UTC(), nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("commit time %q: want RFC 3339 or unix seconds", s)
+	}
+	return t, nil
+}
+
+// grade fills task, deadline and score fields of registry task results.
+// Without a commit time lateness is unknown and no penalty is applied.
+func grade(results map[string]*PackageResult, profile config.Profile, commitTime time.Time) {
+	for pkg, res := range results {
+		task, ok := profile.TaskForPackage(pkg)
+		if !ok {
+			continue
+		}
+		res.Task = task.ID
+		weight := task.EffectiveWeight()
+		res.MaxScore = weight
+
+		score := 0.0
+		if res.Status == "pass" {
+			score = weight
+			// часть веса — только за уложившиеся в лимиты бенчмарки
+			if share := profile.Grading.BenchmarkShare; share > 0 && len(task.Benchmarks) > 0 && res.Performance != "pass" {
+				score = weight * (1 - share)
+			}
+		}
+
+		if d, ok := profile.DeadlineFor(task); ok {
+			res.Deadline = d.At.Format(time.RFC3339)
+			if !commitTime.IsZero() {
+				late := commitTime.Sub(d.At)
+				// в пределах grace — вовремя, как и в Factor
+				onTime := late <= profile.Grading.LatePenalty.Grace()
+				res.OnTime = &onTime
+				if !onTime {
+					res.LateBy = late.Round(time.Minute).String()
+				}
+				score *= profile.Grading.LatePenalty.Factor(late)
+			}
+		}
+
+		score = math.Round(score*100) / 100
+		res.Score = &score
+	}
+}
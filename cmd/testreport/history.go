@@ -0,0 +1,30 @@
+package main
+
+import (
+	"industry_backend_go/internal/history"
+	"time"
+)
+
+// historyRun condenses the report into one history log entry.
+func historyRun(rep *Report, commit string, commitTime time.Time) history.Run {
+	run := history.Run{
+		Commit:     commit,
+		CommitTime: commitTime,
+		RecordedAt: time.Now().UTC().Truncate(time.Second),
+		Stream:     rep.Profile.Name,
+		Packages:   make(map[string]history.Package, len(rep.Packages)),
+	}
+	for pkg, res := range rep.Packages {
+		run.Packages[pkg] = history.Package{
+			Task:           res.Task,
+			Status:         res.Status,
+			Classification: res.Classification,
+			Passed:         res.Counts.Passed,
+			Total:          res.Counts.Total,
+			Flaky:          res.FlakyTests,
+			Score:          res.Score,
+			Performance:    res.Performance,
+		}
+	}
+	return run
+}
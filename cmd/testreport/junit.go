@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Errors   int          `xml:"errors,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Time     float64      `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Errors   int         `xml:"errors,attr"`
+	Skipped  int         `xml:"skipped,attr"`
+	Time     float64     `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Time      float64       `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr,omitempty"`
+	Body    string `xml:",cdata"`
+}
+
+// writeJUnit renders one testsuite per package and one testcase per test
+// or subtest. A package that failed without failing tests (build error,
+// timeout) gets a synthetic "(package)" case with an <error>.
+func writeJUnit(w io.Writer, rep *Report) error {
+	root := junitSuites{Name: "go test"}
+	for _, pkg := range rep.sortedPackages() {
+		res := rep.Packages[pkg]
+		s := junitSuite{Name: pkg, Time: res.Elapsed}
+		for _, t := range flattenTests(res.Tests) {
+			c := junitCase{ClassName: pkg, Name: t.Name, Time: roundSeconds(t.Elapsed / float64(max(t.Runs, 1)))}
+			switch t.Status {
+			case "fail", "flaky":
+				msg := fmt.Sprintf("failed %d of %d runs", t.Failed, t.Runs)
+				if t.Status == "flaky" {
+					msg = "flaky: " + msg
+				}
+				c.Failure = &junitMessage{Message: msg, Body: junitText(t.Output)}
+				s.Failures++
+			case "unknown":
+				c.Error = &junitMessage{Message: "test did not finish", Body: junitText(t.Output)}
+				s.Errors++
+			case "skip":
+				c.Skipped = &junitMessage{}
+				s.Skipped++
+			}
+			s.Cases = append(s.Cases, c)
+		}
+		if res.Status == "fail" && len(res.FailedTests) == 0 {
+			msg := "package failed"
+			if res.Classification != "" && res.Classification != "test_failure" {
+				msg += ": " + strings.ReplaceAll(res.Classification, "_", " ")
+			}
+			s.Cases = append(s.Cases, junitCase{
+				ClassName: pkg,
+				Name:      "(package)",
+				Error:     &junitMessage{Message: msg, Body: junitText(res.Output)},
+			})
+			s.Errors++
+		}
+		s.Tests = len(s.Cases)
+
+		root.Tests += s.Tests
+		root.Failures += s.Failures
+		root.Errors += s.Errors
+		root.Skipped += s.Skipped
+		root.Time += s.Time
+		root.Suites = append(root.Suites, s)
+	}
+	root.Time = roundSeconds(root.Time)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(root); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// junitText strips characters XML 1.0 cannot carry (ANSI escapes etc.).
+func junitText(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\t' || r == '\n' || r == '\r' || r >= 0x20 {
+			return r
+		}
+		return -1
+	}, s)
+}
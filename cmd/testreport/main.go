@@ -1,13 +1,16 @@
 package main
 
 import (
-	"bufio"
-	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"industry_backend_go/internal/config"
+	"industry_backend_go/internal/history"
+	"io"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 )
 
 type TestEvent struct {
@@ -16,11 +19,54 @@ type TestEvent struct {
 	Test    string  `json:"Test,omitempty"`
 	Output  string  `json:"Output,omitempty"`
 	Elapsed float64 `json:"Elapsed,omitempty"`
+
+	// build-output/build-fail events (go 1.24+) carry ImportPath instead of
+	// Package; a package whose build failed names it in FailedBuild.
+	ImportPath  string `json:"ImportPath,omitempty"`
+	FailedBuild string `json:"FailedBuild,omitempty"`
 }
 
 type PackageResult struct {
-	Status      string   `json:"status"` // pass|fail|skip|unknown
-	FailedTests []string `json:"failed_tests,omitempty"`
+	Status string `json:"status"` // pass|fail|skip|unknown
+	State  string `json:"state"`  // Status, or flaky if only flaky tests failed; for badges and the dashboard
+	// Classification says why: pass|build_error|test_failure|panic|timeout|race
+	Classification string        `json:"classification,omitempty"`
+	BuildErrors    []Diagnostic  `json:"build_errors,omitempty"`
+	FailedTests    []string      `json:"failed_tests,omitempty"`
+	FlakyTests     []string      `json:"flaky_tests,omitempty"`
+	Elapsed        float64       `json:"elapsed,omitempty"` // seconds, whole test binary
+	Counts         TestCounts    `json:"counts"`
+	Tests          []*TestResult `json:"tests,omitempty"`
+
+	// вывод пакета вне тестов; заполняется, только если пакет упал без упавших тестов
+	Output string       `json:"output,omitempty"`
+	Races  []RaceReport `json:"races,omitempty"`
+	Panic  *PanicReport `json:"panic,omitempty"`
+
+	// заполняется только с -coverprofile
+	Coverage *Coverage `json:"coverage,omitempty"`
+
+	// результаты бенчмарков из -in или -bench; Performance — только при лимитах в конфиге
+	Benchmarks  []BenchmarkResult `json:"benchmarks,omitempty"`
+	Performance string            `json:"performance,omitempty"` // pass|fail
+
+	testIndex   map[string]*TestResult
+	outputs     map[string]*strings.Builder // по имени теста, "" для пакета
+	maxOutput   int
+	buildFailed bool
+	buildOutput string
+
+	benchPartial string
+	benchSamples map[string][]benchSample
+	benchOrder   []string
+
+	// заполняется только для заданий из реестра
+	Task     string   `json:"task,omitempty"`
+	Deadline string   `json:"deadline,omitempty"`
+	OnTime   *bool    `json:"on_time,omitempty"`
+	LateBy   string   `json:"late_by,omitempty"`
+	Score    *float64 `json:"score,omitempty"`
+	MaxScore float64  `json:"max_score,omitempty"`
 }
 
 func loadPackages(path string) ([]string, error) {
@@ -42,59 +88,79 @@ func loadPackages(path string) ([]string, error) {
 	return pkgs, nil
 }
 
-func ignoredPackage(configPath *string) map[string]struct{} {
+func loadProfile(configPath, overridePath, stream *string) config.Profile {
 	if configPath == nil {
 		fmt.Fprintf(os.Stderr, "non parse config path\n")
 		os.Exit(2)
 	}
-	cfg, err := config.Load(*configPath)
+	resolved, err := config.LoadLayered(config.Options{Path: *configPath, OverridePath: *overridePath, Environ: os.Environ()})
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "create cfg: %v\n", err)
 		os.Exit(2)
 	}
-	ignoredPkgsLines := cfg.Tests.IgnorePackages
-	ignoredPkgs := make(map[string]struct{}, len(ignoredPkgsLines))
-	for _, l := range ignoredPkgsLines {
-		l = strings.TrimSpace(l)
-		if l != "" {
-			ignoredPkgs[l] = struct{}{}
-		}
+	profile, err := resolved.Config.Profile(*stream)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "select stream: %v\n", err)
+		os.Exit(2)
 	}
-	return ignoredPkgs
+	return profile
 }
 
 func main() {
 	inPath := flag.String("in", "", "input file (go test -json output). If empty: read stdin")
-	outPath := flag.String("out", "package-results.json", "output json file")
+	var outs outputList
+	flag.Var(&outs, "out", "output file, repeatable; \"[format:]path\", e.g. -out package-results.json -out junit:junit.xml (default package-results.json)")
+	format := flag.String("format", "json", "format for -out values without a prefix: json, junit, sarif or markdown")
+	root := flag.String("root", ".", "repo root, used to resolve test files for junit/sarif locations")
+	linkBase := flag.String("link-base", "", "prefix for README links in markdown output (default: repo-relative links)")
 	pkgsPath := flag.String("pkgs", "", "optional packages list file (one package per line), e.g. from `go list ./...`")
 	configPath := flag.String("config", "./.etc/config.json", "config file")
+	overridePath := flag.String("override", "", "optional local override config (default: config.local.json next to -config)")
+	stream := flag.String("stream", "", "stream profile (default: $INDUSTRY_STREAM or \"stream\" from config)")
+	commitTimeStr := flag.String("commit-time", "", "commit timestamp for deadline checks (RFC 3339 or unix seconds), e.g. `git log -1 --format=%cI`")
+	maxOutput := flag.Int("max-output", 4096, "max bytes of captured output per failed test (0 = unlimited)")
+	followMode := flag.Bool("follow", false, "read events as they arrive (e.g. go test -json ./... | testreport -follow) and rewrite the report periodically")
+	followInterval := flag.Duration("follow-interval", 2*time.Second, "how often -follow rewrites the report while events arrive")
+	showProgress := flag.Bool("progress", false, "with -follow, print a line to stderr as each package finishes")
+	benchPath := flag.String("bench", "", "optional go test -bench -benchmem -json output, when benchmarks run separately from -in")
+	coverPath := flag.String("coverprofile", "", "optional go test -coverprofile output; adds statement coverage and uncovered lines of solution files")
+	historyPath := flag.String("history", "", "append this run to a history log, e.g. "+history.DefaultPath+" (needs -commit)")
+	commit := flag.String("commit", "", "commit SHA recorded in -history")
+	flakyPath := flag.String("flaky-out", "", "optional json file listing flaky tests (passed on some -count runs, failed on others)")
 	flag.Parse()
 
-	pkgs, err := loadPackages(*pkgsPath)
+	if len(outs) == 0 {
+		outs = outputList{"package-results.json"}
+	}
+	targets, err := parseTargets(outs, *format)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "load pkgs: %v\n", err)
+		fmt.Fprintf(os.Stderr, "parse -out: %v\n", err)
 		os.Exit(2)
 	}
 
-	ignoredPkgs := ignoredPackage(configPath)
-	_ = ignoredPkgs
+	if *historyPath != "" && *commit == "" {
+		fmt.Fprintln(os.Stderr, "-history needs -commit")
+		os.Exit(2)
+	}
 
-	results := map[string]*PackageResult{}
-	ensure := func(pkg string) {
-		if pkg == "" {
-			return
-		}
-		if _, ok := ignoredPkgs[pkg]; ok {
-			return
-		}
-		if _, ok := results[pkg]; !ok {
-			results[pkg] = &PackageResult{Status: "unknown"}
-		}
+	commitTime, err := parseCommitTime(*commitTimeStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "parse commit time: %v\n", err)
+		os.Exit(2)
 	}
 
+	pkgs, err := loadPackages(*pkgsPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "load pkgs: %v\n", err)
+		os.Exit(2)
+	}
+
+	profile := loadProfile(configPath, overridePath, stream)
+
+	col := newCollector(profile, commitTime, *maxOutput)
 	// prefill expected packages (so they appear even if no events were emitted)
 	for _, p := range pkgs {
-		ensure(p)
+		col.ensure(p)
 	}
 
 	var in *os.File
@@ -110,67 +176,95 @@ func main() {
 		in = f
 	}
 
-	sc := bufio.NewScanner(in)
-	// go test output lines can be large (panic stacktrace, long logs)
-	sc.Buffer(make([]byte, 1024), 10*1024*1024)
-
-	for sc.Scan() {
-		line := strings.TrimSpace(sc.Text())
-		if line == "" || !strings.HasPrefix(line, "{") {
-			continue
-		}
-
-		var ev TestEvent
-		if err := json.Unmarshal([]byte(line), &ev); err != nil {
-			// ignore non-json garbage lines
-			continue
+	if *benchPath != "" {
+		if err := col.addBenchFile(*benchPath); err != nil {
+			fmt.Fprintf(os.Stderr, "read benchmarks: %v\n", err)
+			os.Exit(2)
 		}
+	}
 
-		if ev.Package == "" {
-			continue
+	absRoot, err := filepath.Abs(*root)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "resolve root: %v\n", err)
+		os.Exit(2)
+	}
+	rep := &Report{
+		Packages: col.results,
+		Profile:  profile,
+		Root:     absRoot,
+		Module:   readModule(absRoot),
+		LinkBase: *linkBase,
+	}
+	write := func(final bool) error {
+		col.finalize(final)
+		if final && *coverPath != "" {
+			// профиль go test пишет только в конце прогона
+			prof, err := loadCoverProfile(*coverPath)
+			switch {
+			case os.IsNotExist(err):
+				// go test не пишет профиль, если прогон прервался
+				fmt.Fprintf(os.Stderr, "no cover profile %s, skipping coverage\n", *coverPath)
+			case err != nil:
+				return fmt.Errorf("read cover profile: %w", err)
+			default:
+				rep.attachCoverage(prof)
+			}
 		}
-		ensure(ev.Package)
-
-		// package-level result: Action pass/fail/skip and empty Test
-		if ev.Test == "" {
-			if _, ignored := ignoredPkgs[ev.Package]; ignored {
+		for _, t := range targets {
+			if !final && t.path == "-" {
 				continue
 			}
-			switch ev.Action {
-			case "pass":
-				results[ev.Package].Status = "pass"
-			case "fail":
-				results[ev.Package].Status = "fail"
-			case "skip":
-				// sometimes packages get skipped; keep it explicit
-				if results[ev.Package].Status == "unknown" {
-					results[ev.Package].Status = "skip"
-				}
+			if err := writeTarget(t, rep); err != nil {
+				return fmt.Errorf("write %s output %s: %w", t.format, t.path, err)
 			}
-			continue
 		}
+		return nil
+	}
 
-		// test-level fail: Action fail and Test present
-		if ev.Action == "fail" && ev.Test != "" {
-			results[ev.Package].FailedTests = append(results[ev.Package].FailedTests, ev.Test)
+	if *followMode {
+		var progress io.Writer
+		if *showProgress {
+			progress = os.Stderr
+		}
+		err := follow(in, col, rep, *followInterval, progress, write)
+		if errors.Is(err, errInterrupted) {
+			fmt.Fprintln(os.Stderr, "interrupted, partial report written")
+			os.Exit(1)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "follow input: %v\n", err)
+			os.Exit(2)
+		}
+	} else {
+		sc := newScanner(in)
+		for sc.Scan() {
+			col.addLine(sc.Text())
+		}
+		if err := sc.Err(); err != nil {
+			fmt.Fprintf(os.Stderr, "scan input: %v\n", err)
+			os.Exit(2)
+		}
+		if err := write(true); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
 		}
-	}
-	if err := sc.Err(); err != nil {
-		fmt.Fprintf(os.Stderr, "scan input: %v\n", err)
-		os.Exit(2)
 	}
 
-	out, err := os.Create(*outPath)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "create output: %v\n", err)
-		os.Exit(2)
+	if *historyPath != "" {
+		if err := history.Append(*historyPath, historyRun(rep, *commit, commitTime)); err != nil {
+			fmt.Fprintf(os.Stderr, "append history: %v\n", err)
+			os.Exit(2)
+		}
 	}
-	defer out.Close()
 
-	enc := json.NewEncoder(out)
-	enc.SetIndent("", "  ")
-	if err := enc.Encode(results); err != nil {
-		fmt.Fprintf(os.Stderr, "write output: %v\n", err)
-		os.Exit(2)
+	flaky := flakySummary(col.results)
+	for _, f := range flaky {
+		fmt.Printf("FLAKY: %s %s (failed %d of %d runs)\n", f.Package, f.Test, f.Failed, f.Passed+f.Failed)
+	}
+	if *flakyPath != "" {
+		if err := writeFlakySummary(*flakyPath, flaky); err != nil {
+			fmt.Fprintf(os.Stderr, "write flaky summary: %v\n", err)
+			os.Exit(2)
+		}
 	}
 }
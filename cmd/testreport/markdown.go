@@ -0,0 +1,277 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// maxListedTests caps the failed test names shown in one table cell.
+const maxListedTests = 5
+
+var statusEmoji = map[string]string{
+	"pass":    "✅",
+	"fail":    "❌",
+	"flaky":   "⚠️",
+	"skip":    "⏭️",
+	"unknown": "❔",
+}
+
+// writeMarkdown renders a GitHub-flavoured summary: one table row per
+// package plus collapsible failure output. Suitable for $GITHUB_STEP_SUMMARY.
+func writeMarkdown(w io.Writer, rep *Report) error {
+	var b strings.Builder
+
+	passed := 0
+	for _, res := range rep.Packages {
+		if res.Status == "pass" {
+			passed++
+		}
+	}
+	fmt.Fprintf(&b, "## Test results\n\n**%d/%d passing**", passed, len(rep.Packages))
+	if rep.Profile.Name != "" {
+		fmt.Fprintf(&b, " · stream `%s`", rep.Profile.Name)
+	}
+	b.WriteString("\n\n")
+
+	withCoverage := false
+	for _, res := range rep.Packages {
+		withCoverage = withCoverage || res.Coverage != nil
+	}
+
+	b.WriteString("| Task | Status | Tests | Failed tests | Time |")
+	if withCoverage {
+		b.WriteString(" Coverage |")
+	}
+	b.WriteString("\n|---|---|---|---|---|")
+	if withCoverage {
+		b.WriteString("---|")
+	}
+	b.WriteString("\n")
+	for _, pkg := range rep.sortedPackages() {
+		res := rep.Packages[pkg]
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |",
+			rep.mdTaskCell(pkg),
+			mdStatus(res),
+			mdCounts(res),
+			mdFailedTests(res),
+			mdSeconds(res.Elapsed),
+		)
+		if withCoverage {
+			fmt.Fprintf(&b, " %s |", mdCoverage(res.Coverage))
+		}
+		b.WriteString("\n")
+	}
+
+	if withCoverage {
+		rep.mdUncovered(&b)
+	}
+	rep.mdBenchmarks(&b)
+
+	for _, pkg := range rep.sortedPackages() {
+		res := rep.Packages[pkg]
+		for _, t := range flattenTests(res.Tests) {
+			if t.Output == "" || hasFailingSubtest(t) {
+				continue
+			}
+			fmt.Fprintf(&b, "\n<details><summary>%s <code>%s</code> %s</summary>\n\n%s\n</details>\n",
+				statusEmoji[t.Status], html.EscapeString(t.Name), html.EscapeString(rep.packageDir(pkg)), mdCodeBlock(t.Output))
+		}
+		if res.Output != "" {
+			fmt.Fprintf(&b, "\n<details><summary>%s %s</summary>\n\n%s\n</details>\n",
+				statusEmoji["fail"], html.EscapeString(rep.packageDir(pkg)), mdCodeBlock(res.Output))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// mdCodeBlock fences raw output with more backticks than any run inside
+// it, so ``` or </details> in test output cannot end the block early.
+func mdCodeBlock(out string) string {
+	longest, run := 0, 0
+	for _, c := range out {
+		if c == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", max(3, longest+1))
+	return fence + "\n" + strings.TrimRight(out, "\n") + "\n" + fence
+}
+
+// mdTaskCell links registry tasks to their README; other packages are
+// shown by import path.
+func (rep *Report) mdTaskCell(pkg string) string {
+	t, ok := rep.Profile.TaskForPackage(pkg)
+	if !ok {
+		return "`" + pkg + "`"
+	}
+	label := "task " + t.ID
+	if t.Title != "" {
+		label += " — " + mdEscape(t.Title)
+	}
+	return fmt.Sprintf("[%s](%s)", label, rep.link(t.Dir()+"/README.md"))
+}
+
+// link prefixes a repo-relative path with -link-base, if set.
+func (rep *Report) link(rel string) string {
+	if rep.LinkBase == "" {
+		return rel
+	}
+	return strings.TrimSuffix(rep.LinkBase, "/") + "/" + rel
+}
+
+func mdStatus(res *PackageResult) string {
+	s := statusEmoji[res.Status]
+	if s == "" {
+		s = statusEmoji["unknown"]
+	}
+	s += " " + res.Status
+	switch res.Classification {
+	case "", "pass", "test_failure":
+	default:
+		// build_error → "build error": почему пакет красный без упавших тестов
+		s += " · " + strings.ReplaceAll(res.Classification, "_", " ")
+	}
+	if len(res.FlakyTests) > 0 {
+		s += " " + statusEmoji["flaky"] + " flaky"
+	}
+	return s
+}
+
+func mdCounts(res *PackageResult) string {
+	c := res.Counts
+	if c.Total == 0 {
+		return "—"
+	}
+	return fmt.Sprintf("%d/%d", c.Passed, c.Total)
+}
+
+func mdFailedTests(res *PackageResult) string {
+	var names []string
+	for _, d := range res.BuildErrors {
+		names = append(names, "`"+mdEscape(path.Base(d.File)+":"+strconv.Itoa(d.Line)+": "+firstLine(d.Message))+"`")
+	}
+	for _, name := range res.FailedTests {
+		t := res.testIndex[name]
+		if t != nil && hasFailingSubtest(t) {
+			// достаточно показать сам подтест
+			continue
+		}
+		cell := "`" + mdEscape(name) + "`"
+		if t != nil && t.Status == "flaky" {
+			cell += fmt.Sprintf(" %s %d/%d", statusEmoji["flaky"], t.Failed, t.Runs)
+		}
+		names = append(names, cell)
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	if len(names) > maxListedTests {
+		more := len(names) - maxListedTests
+		names = append(names[:maxListedTests], fmt.Sprintf("…and %d more", more))
+	}
+	return strings.Join(names, "<br>")
+}
+
+func mdCoverage(c *Coverage) string {
+	if c == nil {
+		return "—"
+	}
+	return fmt.Sprintf("%.1f%%", c.Percent)
+}
+
+// mdUncovered lists lines of solution files no test reached, linked to the
+// source.
+func (rep *Report) mdUncovered(b *strings.Builder) {
+	var lines []string
+	for _, pkg := range rep.sortedPackages() {
+		c := rep.Packages[pkg].Coverage
+		if c == nil {
+			continue
+		}
+		for _, f := range c.Files {
+			if len(f.Uncovered) == 0 {
+				continue
+			}
+			ranges := make([]string, 0, len(f.Uncovered))
+			for _, r := range f.Uncovered {
+				anchor := fmt.Sprintf("#L%d", r.Start)
+				if r.End != r.Start {
+					anchor += fmt.Sprintf("-L%d", r.End)
+				}
+				ranges = append(ranges, fmt.Sprintf("[%s](%s%s)", r, rep.link(f.File), anchor))
+			}
+			lines = append(lines, fmt.Sprintf("- `%s` (%.1f%%): %s", f.File, f.Percent, strings.Join(ranges, ", ")))
+		}
+	}
+	if len(lines) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n<details><summary>Uncovered lines</summary>\n\n%s\n</details>\n", strings.Join(lines, "\n"))
+}
+
+// mdBenchmarks renders one row per benchmark; limits come from the task
+// registry.
+func (rep *Report) mdBenchmarks(b *strings.Builder) {
+	var rows []string
+	for _, pkg := range rep.sortedPackages() {
+		res := rep.Packages[pkg]
+		for _, bm := range res.Benchmarks {
+			status := ""
+			switch bm.Status {
+			case "pass":
+				status = statusEmoji["pass"]
+			case "fail":
+				status = statusEmoji["fail"] + " " + mdEscape(strings.Join(bm.Violations, "; "))
+			case "missing":
+				status = statusEmoji["unknown"] + " not run"
+			}
+			rows = append(rows, fmt.Sprintf("| %s | `%s` | %s | %s | %s | %s |",
+				mdEscape(rep.packageDir(pkg)), mdEscape(bm.Name), mdNsPerOp(bm), mdInt(bm.BytesPerOp), mdInt(bm.AllocsPerOp), status))
+		}
+	}
+	if len(rows) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n<details><summary>Benchmarks</summary>\n\n| Package | Benchmark | ns/op | B/op | allocs/op | Limits |\n|---|---|---|---|---|---|\n%s\n</details>\n",
+		strings.Join(rows, "\n"))
+}
+
+func mdNsPerOp(bm BenchmarkResult) string {
+	if bm.Runs == 0 {
+		return "—"
+	}
+	return strconv.FormatFloat(bm.NsPerOp, 'g', 4, 64)
+}
+
+func mdInt(v *int64) string {
+	if v == nil {
+		return "—"
+	}
+	return strconv.FormatInt(*v, 10)
+}
+
+func mdSeconds(s float64) string {
+	if s == 0 {
+		return "—"
+	}
+	return fmt.Sprintf("%.2fs", s)
+}
+
+// mdEscape keeps user text from breaking table cells.
+func mdEscape(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
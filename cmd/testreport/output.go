@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"industry_backend_go/internal/config"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Report is what output writers render.
+type Report struct {
+	Packages map[string]*PackageResult
+	Profile  config.Profile
+	Root     string // repo root, used to make file paths relative
+	Module   string // module path from go.mod, used to map packages to dirs
+	LinkBase string // prefix for links in markdown, e.g. https://github.com/o/r/blob/<sha>
+}
+
+// writeFunc renders a report in one format.
+type writeFunc func(w io.Writer, rep *Report) error
+
+var writers = map[string]writeFunc{
+	"json":     writeJSONReport,
+	"junit":    writeJUnit,
+	"sarif":    writeSARIF,
+	"markdown": writeMarkdown,
+}
+
+func formatNames() []string {
+	names := make([]string, 0, len(writers))
+	for n := range writers {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// outputTarget is one -out value: "[format:]path".
+type outputTarget struct {
+	format string
+	path   string
+}
+
+// outputList collects repeated -out flags.
+type outputList []string
+
+func (o *outputList) String() string { return strings.Join(*o, ",") }
+
+func (o *outputList) Set(v string) error {
+	*o = append(*o, v)
+	return nil
+}
+
+// parseTargets resolves -out values; a value without a known "format:"
+// prefix uses defaultFormat.
+func parseTargets(values []string, defaultFormat string) ([]outputTarget, error) {
+	if _, ok := writers[defaultFormat]; !ok {
+		return nil, fmt.Errorf("unknown format %q (want one of %v)", defaultFormat, formatNames())
+	}
+	out := make([]outputTarget, 0, len(values))
+	for _, v := range values {
+		t := outputTarget{format: defaultFormat, path: v}
+		if f, p, ok := strings.Cut(v, ":"); ok {
+			if _, known := writers[f]; known {
+				t = outputTarget{format: f, path: p}
+			}
+		}
+		if t.path == "" {
+			return nil, fmt.Errorf("-out %q: empty path", v)
+		}
+		out = append(out, t)
+	}
+	return out, nil
+}
+
+// writeTarget renders the report into a temp file and renames it, so a
+// reader never sees a half-written artifact. "-" writes to stdout.
+func writeTarget(t outputTarget, rep *Report) error {
+	write := writers[t.format]
+	if t.path == "-" {
+		return write(os.Stdout, rep)
+	}
+	if dir := filepath.Dir(t.path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	tmp := t.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(f)
+	writeErr := write(bw, rep)
+	if writeErr == nil {
+		writeErr = bw.Flush()
+	}
+	closeErr := f.Close()
+	if writeErr != nil {
+		_ = os.Remove(tmp)
+		return writeErr
+	}
+	if closeErr != nil {
+		_ = os.Remove(tmp)
+		return closeErr
+	}
+	return os.Rename(tmp, t.path)
+}
+
+func writeJSONReport(w io.Writer, rep *Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(rep.Packages)
+}
+
+// sortedPackages returns package import paths in a stable order.
+func (rep *Report) sortedPackages() []string {
+	pkgs := make([]string, 0, len(rep.Packages))
+	for p := range rep.Packages {
+		pkgs = append(pkgs, p)
+	}
+	sort.Strings(pkgs)
+	return pkgs
+}
+
+// packageDir maps an import path to a slash-separated dir relative to the
+// repo root.
+func (rep *Report) packageDir(pkg string) string {
+	if t, ok := rep.Profile.TaskForPackage(pkg); ok {
+		return t.Dir()
+	}
+	if rep.Module != "" {
+		if pkg == rep.Module {
+			return "."
+		}
+		if rest, ok := strings.CutPrefix(pkg, rep.Module+"/"); ok {
+			return rest
+		}
+	}
+	return pkg
+}
+
+// relPath makes an absolute file path from a stack trace relative to the
+// repo root; paths outside the root are returned unchanged.
+func (rep *Report) relPath(file string) string {
+	if rep.Root == "" || !filepath.IsAbs(file) {
+		return filepath.ToSlash(file)
+	}
+	rel, err := filepath.Rel(rep.Root, file)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(file)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// readModule returns the module path declared in root/go.mod, or "".
+func readModule(root string) string {
+	b, err := os.ReadFile(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return ""
+	}
+	for _, l := range strings.Split(string(b), "\n") {
+		if rest, ok := strings.CutPrefix(strings.TrimSpace(l), "module "); ok {
+			return strings.Trim(strings.TrimSpace(rest), `"`)
+		}
+	}
+	return ""
+}
+
+// flattenTests walks the test tree depth-first.
+func flattenTests(ts []*TestResult) []*TestResult {
+	var out []*TestResult
+	for _, t := range ts {
+		out = append(out, t)
+		out = append(out, flattenTests(t.Subtests)...)
+	}
+	return out
+}
+
+// joinDir joins a repo-relative dir and a file name.
+func joinDir(dir, file string) string {
+	if dir == "." || dir == "" {
+		return file
+	}
+	return path.Join(dir, file)
+}
@@ -0,0 +1,357 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Minimal SARIF 2.1.0 model: enough for code-scanning style annotations.
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID           string          `json:"ruleId"`
+	Level            string          `json:"level"`
+	Message          sarifMessage    `json:"message"`
+	Locations        []sarifLocation `json:"locations,omitempty"`
+	RelatedLocations []sarifLocation `json:"relatedLocations,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysical `json:"physicalLocation"`
+}
+
+type sarifPhysical struct {
+	ArtifactLocation sarifArtifact `json:"artifactLocation"`
+	Region           *sarifRegion  `json:"region,omitempty"`
+}
+
+type sarifArtifact struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+	EndLine   int `json:"endLine,omitempty"`
+}
+
+var sarifRules = []sarifRule{
+	{ID: "test-failure", ShortDescription: sarifMessage{Text: "Test failed"}},
+	{ID: "flaky-test", ShortDescription: sarifMessage{Text: "Test passed on some -count runs and failed on others"}},
+	{ID: "build-error", ShortDescription: sarifMessage{Text: "Package does not compile"}},
+	{ID: "panic", ShortDescription: sarifMessage{Text: "Test panicked"}},
+	{ID: "timeout", ShortDescription: sarifMessage{Text: "Test did not finish within -timeout"}},
+	{ID: "data-race", ShortDescription: sarifMessage{Text: "Race detector reported a data race"}},
+	{ID: "package-failure", ShortDescription: sarifMessage{Text: "Package failed without a failing test"}},
+	{ID: "uncovered-code", ShortDescription: sarifMessage{Text: "No test reaches these lines"}},
+	{ID: "benchmark-limit", ShortDescription: sarifMessage{Text: "Benchmark exceeds the limits configured for the task"}},
+}
+
+// writeSARIF emits one result per failing test, panic and data race,
+// pointing at the failing line of solution_test.go where it can be found.
+func writeSARIF(w io.Writer, rep *Report) error {
+	var results []sarifResult
+	for _, pkg := range rep.sortedPackages() {
+		res := rep.Packages[pkg]
+		dir := rep.packageDir(pkg)
+
+		for _, t := range flattenTests(res.Tests) {
+			switch t.Status {
+			case "fail", "flaky", "unknown":
+			default:
+				continue
+			}
+			// родительский тест падает вместе с подтестом — отмечаем только подтест
+			if hasFailingSubtest(t) {
+				continue
+			}
+
+			loc := rep.testLocation(dir, t)
+			switch {
+			case t.Panic != nil && strings.HasPrefix(t.Panic.Message, "test timed out"):
+				results = append(results, sarifResult{
+					RuleID:    "timeout",
+					Level:     "error",
+					Message:   sarifMessage{Text: fmt.Sprintf("%s: %s: %s", pkg, t.Name, firstLine(t.Panic.Message))},
+					Locations: loc,
+				})
+			case t.Panic != nil:
+				results = append(results, sarifResult{
+					RuleID:    "panic",
+					Level:     "error",
+					Message:   sarifMessage{Text: fmt.Sprintf("%s: %s: panic: %s", pkg, t.Name, t.Panic.Message)},
+					Locations: rep.panicLocations(t, loc),
+				})
+			case t.Status == "flaky":
+				results = append(results, sarifResult{
+					RuleID:    "flaky-test",
+					Level:     "warning",
+					Message:   sarifMessage{Text: fmt.Sprintf("%s: %s failed %d of %d runs", pkg, t.Name, t.Failed, t.Runs)},
+					Locations: loc,
+				})
+			case t.Status == "fail":
+				results = append(results, sarifResult{
+					RuleID:    "test-failure",
+					Level:     "error",
+					Message:   sarifMessage{Text: fmt.Sprintf("%s: %s failed%s", pkg, t.Name, firstErrorLine(t.Output))},
+					Locations: loc,
+				})
+			}
+
+			for _, r := range t.Races {
+				results = append(results, rep.raceResult(pkg, t.Name, r))
+			}
+		}
+
+		for _, r := range res.Races {
+			results = append(results, rep.raceResult(pkg, "", r))
+		}
+		for _, d := range res.BuildErrors {
+			results = append(results, sarifResult{
+				RuleID:    "build-error",
+				Level:     "error",
+				Message:   sarifMessage{Text: pkg + ": " + d.Message},
+				Locations: []sarifLocation{fileLocation(rep.relPath(d.File), d.Line)},
+			})
+		}
+		for _, bm := range res.Benchmarks {
+			var msg string
+			switch bm.Status {
+			case "fail":
+				msg = fmt.Sprintf("%s: %s: %s", pkg, bm.Name, strings.Join(bm.Violations, "; "))
+			case "missing":
+				msg = fmt.Sprintf("%s: %s has limits configured but did not run", pkg, bm.Name)
+			default:
+				continue
+			}
+			locs := rep.packageLocations(pkg)
+			top, _, _ := strings.Cut(bm.Name, "/")
+			if file, line, ok := rep.findTestFunc(dir, top); ok {
+				locs = []sarifLocation{fileLocation(joinDir(dir, file), line)}
+			}
+			results = append(results, sarifResult{
+				RuleID:    "benchmark-limit",
+				Level:     "warning",
+				Message:   sarifMessage{Text: msg},
+				Locations: locs,
+			})
+		}
+		if res.Coverage != nil {
+			for _, f := range res.Coverage.Files {
+				for _, r := range f.Uncovered {
+					what := "lines"
+					if r.Start == r.End {
+						what = "line"
+					}
+					l := fileLocation(f.File, r.Start)
+					l.PhysicalLocation.Region.EndLine = r.End
+					results = append(results, sarifResult{
+						RuleID:    "uncovered-code",
+						Level:     "note",
+						Message:   sarifMessage{Text: fmt.Sprintf("%s: %s %s not reached by any test", f.File, what, r)},
+						Locations: []sarifLocation{l},
+					})
+				}
+			}
+		}
+		if res.Status == "fail" && len(res.FailedTests) == 0 && len(res.BuildErrors) == 0 {
+			results = append(results, sarifResult{
+				RuleID:    "package-failure",
+				Level:     "error",
+				Message:   sarifMessage{Text: pkg + ": package failed (" + res.Classification + ")" + firstErrorLine(res.Output)},
+				Locations: rep.packageLocations(pkg),
+			})
+		}
+	}
+	if results == nil {
+		results = []sarifResult{}
+	}
+
+	log := sarifLog{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs: []sarifRun{{
+			Tool:    sarifTool{Driver: sarifDriver{Name: "testreport", Rules: sarifRules}},
+			Results: results,
+		}},
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(log)
+}
+
+func hasFailingSubtest(t *TestResult) bool {
+	for _, s := range t.Subtests {
+		if s.Status == "fail" || s.Status == "flaky" || s.Status == "unknown" {
+			return true
+		}
+	}
+	return false
+}
+
+func (rep *Report) raceResult(pkg, test string, r RaceReport) sarifResult {
+	var locs []sarifLocation
+	for _, a := range r.Accesses {
+		if l, ok := rep.absLocation(a.Location); ok {
+			locs = append(locs, l)
+		}
+	}
+	name := pkg
+	if test != "" {
+		name += ": " + test
+	}
+	res := sarifResult{
+		RuleID:  "data-race",
+		Level:   "error",
+		Message: sarifMessage{Text: name + ": data race between " + rep.describeAccesses(r.Accesses)},
+	}
+	if len(locs) > 0 {
+		res.Locations, res.RelatedLocations = locs[:1], locs[1:]
+	}
+	return res
+}
+
+func (rep *Report) describeAccesses(as []RaceAccess) string {
+	parts := make([]string, 0, len(as))
+	for _, a := range as {
+		who := "main goroutine"
+		if a.Goroutine != 0 {
+			who = "goroutine " + strconv.Itoa(a.Goroutine)
+		}
+		parts = append(parts, fmt.Sprintf("%s by %s at %s", a.Kind, who, rep.relPath(a.Location)))
+	}
+	return strings.Join(parts, " and ")
+}
+
+func (rep *Report) panicLocations(t *TestResult, fallback []sarifLocation) []sarifLocation {
+	if l, ok := rep.absLocation(t.Panic.Location); ok {
+		return []sarifLocation{l}
+	}
+	return fallback
+}
+
+// absLocation turns "file:line" from a stack trace into a location.
+func (rep *Report) absLocation(loc string) (sarifLocation, bool) {
+	i := strings.LastIndex(loc, ":")
+	if i <= 0 {
+		return sarifLocation{}, false
+	}
+	line, err := strconv.Atoi(loc[i+1:])
+	if err != nil {
+		return sarifLocation{}, false
+	}
+	return fileLocation(rep.relPath(loc[:i]), line), true
+}
+
+// testLogRe matches t.Errorf/t.Fatalf lines such as
+// "    solution_test.go:42: want 1, got 2". Lines from testing.go itself
+// (e.g. "race detected during execution of test") are skipped by callers.
+var testLogRe = regexp.MustCompile(`^\s+([^\s:/]+\.go):(\d+): `)
+
+// testLocation finds where a test failed: the first t.Error line in its
+// output, falling back to the declaration of the top-level test function.
+func (rep *Report) testLocation(dir string, t *TestResult) []sarifLocation {
+	for _, l := range strings.Split(t.Output, "\n") {
+		if m := testLogRe.FindStringSubmatch(l); m != nil && m[1] != "testing.go" {
+			line, _ := strconv.Atoi(m[2])
+			return []sarifLocation{fileLocation(joinDir(dir, m[1]), line)}
+		}
+	}
+	top, _, _ := strings.Cut(t.Name, "/")
+	if file, line, ok := rep.findTestFunc(dir, top); ok {
+		return []sarifLocation{fileLocation(joinDir(dir, file), line)}
+	}
+	return nil
+}
+
+// packageLocations points a package-level result at the task's editable
+// files: a directory is not a valid artifact for code scanning. Packages
+// outside the registry get no location.
+func (rep *Report) packageLocations(pkg string) []sarifLocation {
+	t, ok := rep.Profile.TaskForPackage(pkg)
+	if !ok {
+		return nil
+	}
+	var locs []sarifLocation
+	for _, f := range t.EditableFiles() {
+		if !strings.ContainsAny(f, "*?[") {
+			locs = append(locs, fileLocation(f, 0))
+		}
+	}
+	return locs
+}
+
+// findTestFunc scans *_test.go files in dir for "func <name>(".
+func (rep *Report) findTestFunc(dir, name string) (string, int, bool) {
+	files, _ := filepath.Glob(filepath.Join(rep.Root, filepath.FromSlash(dir), "*_test.go"))
+	prefix := "func " + name + "("
+	for _, f := range files {
+		fh, err := os.Open(f)
+		if err != nil {
+			continue
+		}
+		sc := bufio.NewScanner(fh)
+		for n := 1; sc.Scan(); n++ {
+			if strings.HasPrefix(sc.Text(), prefix) {
+				fh.Close()
+				return filepath.Base(f), n, true
+			}
+		}
+		fh.Close()
+	}
+	return "", 0, false
+}
+
+func fileLocation(uri string, line int) sarifLocation {
+	l := sarifLocation{PhysicalLocation: sarifPhysical{
+		ArtifactLocation: sarifArtifact{URI: uri, URIBaseID: "%SRCROOT%"},
+	}}
+	if line > 0 {
+		l.PhysicalLocation.Region = &sarifRegion{StartLine: line}
+	}
+	return l
+}
+
+// firstErrorLine returns ": <first t.Error message>" or "".
+func firstErrorLine(out string) string {
+	for _, l := range strings.Split(out, "\n") {
+		if m := testLogRe.FindStringSubmatch(l); m != nil && m[1] != "testing.go" {
+			return ": " + strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(l), m[1]+":"+m[2]+":"))
+		}
+	}
+	return ""
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"math"
+	"strings"
+)
+
+// TestResult aggregates every run of one test (or t.Run subtest) across
+// -count repetitions.
+type TestResult struct {
+	Name       string  `json:"name"` // full name, e.g. TestParallelMap/workers=0
+	Status     string  `json:"status"`
+	Runs       int     `json:"runs"`
+	Passed     int     `json:"passed"`
+	Failed     int     `json:"failed"`
+	Skipped    int     `json:"skipped"`
+	Elapsed    float64 `json:"elapsed"`     // seconds, summed over runs
+	MaxElapsed float64 `json:"max_elapsed"` // slowest single run
+	// FlakyRatio is the share of failed runs for a test that both passed
+	// and failed across repetitions. A test with a data race is "fail": the
+	// race detector fails only the first run of it per test binary.
+	FlakyRatio float64 `json:"flaky_ratio,omitempty"`
+
+	// Output of the first failed (or never finished) run, trimmed to
+	// -max-output bytes; races and panics are parsed from the full text.
+	Output string       `json:"output,omitempty"`
+	Races  []RaceReport `json:"races,omitempty"`
+	Panic  *PanicReport `json:"panic,omitempty"`
+
+	Subtests []*TestResult `json:"subtests,omitempty"`
+}
+
+// TestCounts counts distinct tests (subtests included) by final status.
+type TestCounts struct {
+	Total   int `json:"total"`
+	Passed  int `json:"passed"`
+	Failed  int `json:"failed"`
+	Flaky   int `json:"flaky"`
+	Skipped int `json:"skipped"`
+}
+
+// test finds or creates the node for a full test name, creating parents
+// for subtests on the way.
+func (r *PackageResult) test(name string) *TestResult {
+	if t, ok := r.testIndex[name]; ok {
+		return t
+	}
+	if r.testIndex == nil {
+		r.testIndex = map[string]*TestResult{}
+	}
+	t := &TestResult{Name: name, Status: "unknown"}
+	r.testIndex[name] = t
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		parent := r.test(name[:i])
+		parent.Subtests = append(parent.Subtests, t)
+	} else {
+		r.Tests = append(r.Tests, t)
+	}
+	return t
+}
+
+// maxRawOutput bounds the per-test buffer so a chatty test cannot eat the
+// runner's memory.
+const maxRawOutput = 1 << 20
+
+// recordTest applies a test-level event.
+func (r *PackageResult) recordTest(ev TestEvent) {
+	switch ev.Action {
+	case "run":
+		r.test(ev.Test).Runs++
+		// вывод храним только для текущего повтора
+		if b, ok := r.outputs[ev.Test]; ok {
+			b.Reset()
+		}
+	case "output":
+		if r.outputs == nil {
+			r.outputs = map[string]*strings.Builder{}
+		}
+		b, ok := r.outputs[ev.Test]
+		if !ok {
+			b = &strings.Builder{}
+			r.outputs[ev.Test] = b
+		}
+		if b.Len() < maxRawOutput {
+			b.WriteString(ev.Output)
+		}
+	case "pass", "fail", "skip":
+		t := r.test(ev.Test)
+		switch ev.Action {
+		case "pass":
+			t.Passed++
+		case "fail":
+			t.Failed++
+		case "skip":
+			t.Skipped++
+		}
+		t.Elapsed += ev.Elapsed
+		t.MaxElapsed = math.Max(t.MaxElapsed, ev.Elapsed)
+		if ev.Action == "fail" && t.Output == "" {
+			r.captureOutput(t)
+		}
+	}
+}
+
+// captureOutput attaches the buffered output of the current run to t.
+func (r *PackageResult) captureOutput(t *TestResult) {
+	b, ok := r.outputs[t.Name]
+	if !ok || b.Len() == 0 {
+		return
+	}
+	raw := b.String()
+	t.Output = trimOutput(raw, r.maxOutput)
+	t.Races = parseRaces(raw)
+	t.Panic = parsePanic(raw)
+}
+
+// finalizeTests derives statuses and package counts. final is false for
+// intermediate -follow snapshots: until the package itself has finished,
+// its unfinished tests may still be running.
+func (r *PackageResult) finalizeTests(final bool) {
+	done := final || r.Status != "unknown"
+	r.Counts = TestCounts{}
+	r.FlakyTests = nil
+	var walk func(ts []*TestResult)
+	walk = func(ts []*TestResult) {
+		for _, t := range ts {
+			if done && t.Output == "" && t.Runs > t.Passed+t.Failed+t.Skipped {
+				// тест не завершился (panic в другом тесте, timeout)
+				r.captureOutput(t)
+			}
+			t.Status = t.status()
+			if t.Status == "flaky" {
+				t.FlakyRatio = math.Round(float64(t.Failed)/float64(t.Passed+t.Failed)*100) / 100
+				r.FlakyTests = append(r.FlakyTests, t.Name)
+			}
+			if done {
+				t.Elapsed = roundSeconds(t.Elapsed)
+				t.MaxElapsed = roundSeconds(t.MaxElapsed)
+			}
+			r.Counts.Total++
+			switch t.Status {
+			case "pass":
+				r.Counts.Passed++
+			case "fail":
+				r.Counts.Failed++
+			case "flaky":
+				r.Counts.Flaky++
+			case "skip":
+				r.Counts.Skipped++
+			}
+			walk(t.Subtests)
+		}
+	}
+	walk(r.Tests)
+
+	if b, ok := r.outputs[""]; ok && r.Status == "fail" {
+		raw := b.String()
+		if len(r.FailedTests) == 0 {
+			r.Output = trimOutput(raw, r.maxOutput)
+		}
+		r.Races = parseRaces(raw)
+		r.Panic = parsePanic(raw)
+		if buildFailedRe.MatchString(raw) {
+			r.buildFailed = true
+		}
+	}
+	if r.buildOutput != "" {
+		// "FAIL pkg [build failed]" ничего не объясняет, показываем компилятор
+		r.Output = trimOutput(r.buildOutput, r.maxOutput)
+	}
+	r.Classification = r.classify()
+	r.State = r.state()
+}
+
+func (t *TestResult) status() string {
+	switch {
+	case t.Failed > 0 && len(t.Races) > 0:
+		// race detector валит тест один раз на бинарь, остальные повторы
+		// проходят — это гонка, а не нестабильный тест
+		return "fail"
+	case t.Failed > 0 && t.Passed > 0:
+		// прошёл на одних повторах -count и упал на других
+		return "flaky"
+	case t.Failed > 0:
+		return "fail"
+	case t.Passed > 0:
+		return "pass"
+	case t.Skipped > 0:
+		return "skip"
+	default:
+		// started but never finished: panic, timeout or -failfast abort
+		return "unknown"
+	}
+}
+
+func roundSeconds(s float64) float64 {
+	return math.Round(s*1000) / 1000
+}
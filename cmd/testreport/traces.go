@@ -0,0 +1,190 @@
+package main
+
+import (
+	"go/build"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Frame is one call of a goroutine stack.
+type Frame struct {
+	Func string `json:"func"`
+	File string `json:"file,omitempty"`
+	Line int    `json:"line,omitempty"`
+}
+
+func (f Frame) Location() string {
+	if f.File == "" {
+		return ""
+	}
+	return f.File + ":" + strconv.Itoa(f.Line)
+}
+
+type GoroutineStack struct {
+	ID     int     `json:"id"`
+	State  string  `json:"state,omitempty"` // running, chan receive, finished...
+	Frames []Frame `json:"frames,omitempty"`
+}
+
+// RaceAccess is one side of a data race, e.g. "Read at ... by goroutine 10".
+type RaceAccess struct {
+	Kind      string  `json:"kind"` // read, write, previous write...
+	Addr      string  `json:"addr"`
+	Goroutine int     `json:"goroutine"` // 0 for the main goroutine
+	Location  string  `json:"location,omitempty"`
+	Frames    []Frame `json:"frames,omitempty"`
+}
+
+// RaceReport is a parsed "WARNING: DATA RACE" block.
+type RaceReport struct {
+	Accesses   []RaceAccess     `json:"accesses"`
+	Goroutines []GoroutineStack `json:"goroutines,omitempty"` // where the racing goroutines were created
+}
+
+type PanicReport struct {
+	Message    string           `json:"message"`
+	Location   string           `json:"location,omitempty"` // first frame outside runtime and testing
+	Goroutines []GoroutineStack `json:"goroutines,omitempty"`
+}
+
+var (
+	raceAccessRe    = regexp.MustCompile(`^((?:Previous )?(?:[Aa]tomic )?(?:[Rr]ead|[Ww]rite))(?: of size \d+)? at (0x[0-9a-f]+) by (?:goroutine (\d+)|main goroutine):$`)
+	raceGoroutineRe = regexp.MustCompile(`^Goroutine (\d+) \(([^)]*)\) created at:$`)
+	goroutineRe     = regexp.MustCompile(`^goroutine (\d+) \[([^\]]+)\]:$`)
+	fileLineRe      = regexp.MustCompile(`^\s+(\S.*\.go|\S+):(\d+)(?: \+0x[0-9a-f]+)?$`)
+)
+
+// parseRaces extracts every data race block from test output.
+func parseRaces(out string) []RaceReport {
+	var races []RaceReport
+	lines := strings.Split(out, "\n")
+	for i := 0; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) != "WARNING: DATA RACE" {
+			continue
+		}
+		var r RaceReport
+		for i++; i < len(lines) && !strings.HasPrefix(lines[i], "=================="); i++ {
+			line := strings.TrimRight(lines[i], " \r")
+			if m := raceAccessRe.FindStringSubmatch(line); m != nil {
+				g, _ := strconv.Atoi(m[3])
+				var frames []Frame
+				frames, i = parseFrames(lines, i+1)
+				r.Accesses = append(r.Accesses, RaceAccess{
+					Kind:      strings.ToLower(m[1]),
+					Addr:      m[2],
+					Goroutine: g,
+					Location:  userLocation(frames),
+					Frames:    frames,
+				})
+				continue
+			}
+			if m := raceGoroutineRe.FindStringSubmatch(line); m != nil {
+				g, _ := strconv.Atoi(m[1])
+				var frames []Frame
+				frames, i = parseFrames(lines, i+1)
+				r.Goroutines = append(r.Goroutines, GoroutineStack{ID: g, State: m[2], Frames: frames})
+			}
+		}
+		if len(r.Accesses) > 0 {
+			races = append(races, r)
+		}
+	}
+	return races
+}
+
+// parsePanic extracts the first panic (including "test timed out") and the
+// goroutine dump that follows it.
+func parsePanic(out string) *PanicReport {
+	lines := strings.Split(out, "\n")
+	start := -1
+	for i, l := range lines {
+		if strings.HasPrefix(l, "panic: ") {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return nil
+	}
+
+	p := &PanicReport{Message: strings.TrimPrefix(lines[start], "panic: ")}
+	i := start + 1
+	// продолжение сообщения (например, список зависших тестов при timeout)
+	for ; i < len(lines) && strings.TrimSpace(lines[i]) != "" && !goroutineRe.MatchString(lines[i]); i++ {
+		p.Message += "\n" + strings.TrimSpace(lines[i])
+	}
+
+	for ; i < len(lines); i++ {
+		m := goroutineRe.FindStringSubmatch(strings.TrimSpace(lines[i]))
+		if m == nil {
+			continue
+		}
+		id, _ := strconv.Atoi(m[1])
+		var frames []Frame
+		frames, i = parseFrames(lines, i+1)
+		p.Goroutines = append(p.Goroutines, GoroutineStack{ID: id, State: m[2], Frames: frames})
+	}
+	for _, g := range p.Goroutines {
+		if loc := userLocation(g.Frames); loc != "" {
+			p.Location = loc
+			break
+		}
+	}
+	return p
+}
+
+// parseFrames reads "func(...)" / "\tfile:line +0x.." pairs starting at
+// lines[i] and returns them with the index of the last consumed line.
+func parseFrames(lines []string, i int) ([]Frame, int) {
+	var frames []Frame
+	for ; i < len(lines); i++ {
+		fn := strings.TrimSpace(lines[i])
+		if fn == "" || strings.HasPrefix(fn, "==================") || goroutineRe.MatchString(fn) {
+			break
+		}
+		f := Frame{Func: fn}
+		if i+1 < len(lines) {
+			if m := fileLineRe.FindStringSubmatch(lines[i+1]); m != nil {
+				f.File = m[1]
+				f.Line, _ = strconv.Atoi(m[2])
+				i++
+			}
+		}
+		frames = append(frames, f)
+	}
+	return frames, i - 1
+}
+
+// userLocation returns file:line of the first frame that is not part of the
+// standard library (runtime, testing, time...).
+func userLocation(frames []Frame) string {
+	goroot := strings.TrimSuffix(filepath.ToSlash(build.Default.GOROOT), "/")
+	for _, f := range frames {
+		switch {
+		case f.File == "",
+			goroot != "" && strings.HasPrefix(f.File, goroot+"/src/"),
+			strings.HasPrefix(f.Func, "runtime."),
+			strings.HasPrefix(f.Func, "testing."),
+			strings.HasPrefix(f.Func, "panic("),
+			strings.HasPrefix(f.Func, "created by "),
+			strings.HasPrefix(f.Func, "main.main("),
+			strings.HasSuffix(f.File, "_testmain.go"):
+			continue
+		}
+		return f.Location()
+	}
+	return ""
+}
+
+// trimOutput keeps the head and the tail of s within max bytes.
+func trimOutput(s string, max int) string {
+	if max <= 0 || len(s) <= max {
+		return s
+	}
+	half := max / 2
+	head := strings.ToValidUTF8(s[:half], "")
+	tail := strings.ToValidUTF8(s[len(s)-half:], "")
+	return head + "\n... [" + strconv.Itoa(len(s)-2*half) + " bytes trimmed] ...\n" + tail
+}
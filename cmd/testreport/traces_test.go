@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"industry_backend_go/internal/config"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Фикстуры в testdata — настоящий вывод go test -json (race.jsonl — с -race)
+// для пакетов из временного модуля fx.
+
+// testOutput joins the output of one test (or of the package itself for
+// test == "") from a go test -json file.
+func testOutput(t *testing.T, name, test string) string {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var b strings.Builder
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var ev TestEvent
+		if err := json.Unmarshal(sc.Bytes(), &ev); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if ev.Action == "output" && ev.Test == test {
+			b.WriteString(ev.Output)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
+
+// collectFile runs a go test -json file through the collector the way
+// testreport does, without a config.
+func collectFile(t *testing.T, name string) map[string]*PackageResult {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := newCollector(config.Profile{}, time.Time{}, 0)
+	for _, line := range strings.Split(string(b), "\n") {
+		c.addLine(line)
+	}
+	c.finalize(true)
+	return c.results
+}
+
+func TestParseRaces(t *testing.T) {
+	t.Parallel()
+
+	type access struct {
+		kind      string
+		addr      string
+		goroutine int
+		location  string
+	}
+	type goroutine struct {
+		id       int
+		state    string
+		location string
+	}
+	tests := []struct {
+		file, test string
+		accesses   []access
+		goroutines []goroutine
+	}{
+		{
+			file: "race.jsonl", test: "TestCounter",
+			accesses: []access{
+				{"read", "0x00c0000182b8", 8, "/tmp/fx/racy/racy_test.go:15"},
+				{"previous write", "0x00c0000182b8", 9, "/tmp/fx/racy/racy_test.go:15"},
+			},
+			goroutines: []goroutine{
+				{8, "running", "/tmp/fx/racy/racy_test.go:13"},
+				{9, "finished", "/tmp/fx/racy/racy_test.go:13"},
+			},
+		},
+		{file: "panic.jsonl", test: "TestLookup"}, // паника — не гонка
+	}
+	for _, tt := range tests {
+		races := parseRaces(testOutput(t, tt.file, tt.test))
+		if len(tt.accesses) == 0 {
+			if len(races) != 0 {
+				t.Errorf("%s %s: parseRaces = %+v, want none", tt.file, tt.test, races)
+			}
+			continue
+		}
+		if len(races) != 1 {
+			t.Fatalf("%s %s: got %d races, want 1", tt.file, tt.test, len(races))
+		}
+		r := races[0]
+		if len(r.Accesses) != len(tt.accesses) {
+			t.Fatalf("%s %s: Accesses = %+v", tt.file, tt.test, r.Accesses)
+		}
+		for i, want := range tt.accesses {
+			a := r.Accesses[i]
+			got := access{a.Kind, a.Addr, a.Goroutine, a.Location}
+			if got != want {
+				t.Errorf("%s %s: access %d = %+v, want %+v", tt.file, tt.test, i, got, want)
+			}
+			if len(a.Frames) == 0 {
+				t.Errorf("%s %s: access %d has no frames", tt.file, tt.test, i)
+			}
+		}
+		if len(r.Goroutines) != len(tt.goroutines) {
+			t.Fatalf("%s %s: Goroutines = %+v", tt.file, tt.test, r.Goroutines)
+		}
+		for i, want := range tt.goroutines {
+			g := r.Goroutines[i]
+			// стек создания: тест, затем testing.tRunner и testing.(*T).Run.gowrap1
+			if len(g.Frames) != 3 {
+				t.Errorf("%s %s: goroutine %d frames = %+v", tt.file, tt.test, g.ID, g.Frames)
+				continue
+			}
+			got := goroutine{g.ID, g.State, g.Frames[0].Location()}
+			if got != want {
+				t.Errorf("%s %s: goroutine %d = %+v, want %+v", tt.file, tt.test, i, got, want)
+			}
+		}
+	}
+}
+
+func TestParsePanic(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		file, test string
+		message    string // "" — паники нет
+		location   string
+		goroutine  int
+		funcs      []string
+	}{
+		{
+			file: "panic.jsonl", test: "TestLookup",
+			message:   "runtime error: index out of range [0] with length 0 [recovered, repanicked]",
+			location:  "/tmp/fx/panicky/panicky_test.go:6",
+			goroutine: 7,
+			funcs: []string{
+				"testing.tRunner.func1.2({0x6c9538, 0x9296f5a20f0})",
+				"testing.tRunner.func1()",
+				"panic({0x6c9538?, 0x9296f5a20f0?})",
+				"fx/panicky.lookup(...)",
+				"fx/panicky.TestLookup(0x9296f61e488)",
+				"testing.tRunner(0x9296f61e488, 0x6d4c70)",
+				"created by testing.(*T).Run in goroutine 1",
+			},
+		},
+		{file: "panic.jsonl", test: "TestOK"},
+		{file: "race.jsonl", test: "TestCounter"},
+	}
+	for _, tt := range tests {
+		p := parsePanic(testOutput(t, tt.file, tt.test))
+		if tt.message == "" {
+			if p != nil {
+				t.Errorf("%s %s: parsePanic = %+v, want nil", tt.file, tt.test, p)
+			}
+			continue
+		}
+		if p == nil {
+			t.Fatalf("%s %s: no panic", tt.file, tt.test)
+		}
+		if p.Message != tt.message {
+			t.Errorf("%s %s: Message = %q, want %q", tt.file, tt.test, p.Message, tt.message)
+		}
+		if p.Location != tt.location {
+			t.Errorf("%s %s: Location = %q, want %q", tt.file, tt.test, p.Location, tt.location)
+		}
+		if len(p.Goroutines) != 1 || p.Goroutines[0].ID != tt.goroutine || p.Goroutines[0].State != "running" {
+			t.Fatalf("%s %s: Goroutines = %+v", tt.file, tt.test, p.Goroutines)
+		}
+		frames := p.Goroutines[0].Frames
+		if len(frames) != len(tt.funcs) {
+			t.Fatalf("%s %s: frames = %+v", tt.file, tt.test, frames)
+		}
+		for i, fn := range tt.funcs {
+			if frames[i].Func != fn {
+				t.Errorf("%s %s: frame %d = %q, want %q", tt.file, tt.test, i, frames[i].Func, fn)
+			}
+		}
+		// у fx/panicky.lookup(...) есть file:line без +0x смещения (инлайнинг)
+		if got := frames[3].Location(); got != tt.location {
+			t.Errorf("%s %s: inlined frame at %q, want %q", tt.file, tt.test, got, tt.location)
+		}
+	}
+}
+
+// TestCaptureOutput checks that the parsed traces end up on the failed
+// test, not on its package or on neighbours that passed.
+func TestCaptureOutput(t *testing.T) {
+	t.Parallel()
+
+	race := collectFile(t, "race.jsonl")["fx/racy"]
+	if race == nil || len(race.Tests) != 1 {
+		t.Fatalf("race results = %+v", race)
+	}
+	if tr := race.Tests[0]; tr.Status != "fail" || len(tr.Races) != 1 || tr.Panic != nil || tr.Output == "" {
+		t.Errorf("TestCounter = %+v", tr)
+	}
+	if len(race.Races) != 0 {
+		t.Errorf("package races = %+v, want them on the test only", race.Races)
+	}
+
+	pan := collectFile(t, "panic.jsonl")["fx/panicky"]
+	if pan == nil || len(pan.Tests) != 2 {
+		t.Fatalf("panic results = %+v", pan)
+	}
+	ok, lookup := pan.Tests[0], pan.Tests[1]
+	if ok.Status != "pass" || ok.Output != "" || ok.Panic != nil {
+		t.Errorf("TestOK = %+v", ok)
+	}
+	if lookup.Status != "fail" || lookup.Panic == nil || lookup.Panic.Location != "/tmp/fx/panicky/panicky_test.go:6" {
+		t.Errorf("TestLookup = %+v", lookup)
+	}
+}
@@ -0,0 +1,221 @@
+// Package badge renders shields.io-style SVG badges locally, so badge
+// generation does not depend on an external service. The flat, flat-square
+// and for-the-badge styles are reproduced; text is measured with built-in
+// Verdana metrics.
+package badge
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"math"
+	"strings"
+)
+
+// Styles rendered locally.
+const (
+	Flat        = "flat"
+	FlatSquare  = "flat-square"
+	ForTheBadge = "for-the-badge"
+)
+
+// Styles lists the supported style names.
+func Styles() []string { return []string{Flat, FlatSquare, ForTheBadge} }
+
+// Supported reports whether style can be rendered locally.
+func Supported(style string) bool {
+	for _, s := range Styles() {
+		if s == style {
+			return true
+		}
+	}
+	return false
+}
+
+// Badge is a two-segment badge. Colours are shields names or hex; an empty
+// LabelColor is the shields default grey. Logo is an image URL, normally a
+// data: URI, drawn 14px square before the label.
+type Badge struct {
+	Label      string
+	Message    string
+	Color      string
+	LabelColor string
+	Logo       string
+}
+
+const (
+	logoSize = 14
+	logoGap  = 3
+)
+
+const fontFamily = "Verdana,Geneva,DejaVu Sans,sans-serif"
+
+// segment is one coloured rectangle with its centred text, in pixels.
+// Text is centred in the part of the segment after the logo, if any.
+type segment struct {
+	text      string
+	fill      string
+	x, width  int
+	logo      int // px taken by the logo at the left
+	textWidth float64
+	bold      bool
+}
+
+// textX is the text centre in the tenth-pixel units the templates use.
+func (s segment) textX() int {
+	return s.x*10 + s.logo*10 + (s.width-s.logo)*5
+}
+
+// Render returns the SVG of b in style ("" is flat).
+func Render(b Badge, style string) ([]byte, error) {
+	if style == "" {
+		style = Flat
+	}
+	if !Supported(style) {
+		return nil, fmt.Errorf("badge: style %q is not supported (want one of %s)", style, strings.Join(Styles(), ", "))
+	}
+	color, err := ParseColor(b.Color)
+	if err != nil {
+		return nil, err
+	}
+	labelColor := "#555"
+	if b.LabelColor != "" {
+		if labelColor, err = ParseColor(b.LabelColor); err != nil {
+			return nil, err
+		}
+	}
+
+	label, msg := strings.TrimSpace(b.Label), strings.TrimSpace(b.Message)
+	if style == ForTheBadge {
+		label, msg = strings.ToUpper(label), strings.ToUpper(msg)
+	}
+	title := msg
+	if label != "" {
+		title = label + ": " + msg
+	}
+
+	var segs []segment
+	x := 0
+	pad := 5
+	if style == ForTheBadge {
+		pad = 12
+	}
+	add := func(text, fill string, bold, logo bool) {
+		s := segment{text: text, fill: fill, x: x, bold: bold}
+		if style == ForTheBadge {
+			// 10px, межбуквенный интервал 1.25px
+			s.textWidth = TextWidth(text, 10, bold) + 1.25*float64(len([]rune(text)))
+		} else {
+			s.textWidth = TextWidth(text, 11, false)
+		}
+		s.width = int(math.Round(s.textWidth)) + 2*pad
+		if logo {
+			s.logo = logoSize + logoGap
+			if text == "" {
+				s.logo, s.width = logoSize, logoSize+2*pad
+			}
+			s.width += s.logo
+		}
+		x += s.width
+		segs = append(segs, s)
+	}
+	hasLogo := b.Logo != ""
+	if label != "" || hasLogo {
+		add(label, labelColor, false, hasLogo)
+	}
+	add(msg, color, style == ForTheBadge, false)
+
+	var buf bytes.Buffer
+	height := 20
+	switch style {
+	case Flat:
+		writeFlat(&buf, title, x, segs)
+	default:
+		writeSquare(&buf, title, x, segs, style == ForTheBadge)
+		if style == ForTheBadge {
+			height = 28
+		}
+	}
+	if hasLogo {
+		fmt.Fprintf(&buf, `<image x="%d" y="%d" width="%d" height="%d" href="%s"/>`,
+			pad, (height-logoSize)/2, logoSize, logoSize, esc(b.Logo))
+	}
+	buf.WriteString(`</svg>`)
+	return buf.Bytes(), nil
+}
+
+func writeFlat(buf *bytes.Buffer, title string, width int, segs []segment) {
+	openSVG(buf, title, width, 20)
+	buf.WriteString(`<linearGradient id="s" x2="0" y2="100%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>`)
+	fmt.Fprintf(buf, `<clipPath id="r"><rect width="%d" height="20" rx="3" fill="#fff"/></clipPath>`, width)
+	buf.WriteString(`<g clip-path="url(#r)">`)
+	writeRects(buf, segs, 20)
+	fmt.Fprintf(buf, `<rect width="%d" height="20" fill="url(#s)"/>`, width)
+	buf.WriteString(`</g>`)
+	fmt.Fprintf(buf, `<g fill="#fff" text-anchor="middle" font-family="%s" text-rendering="geometricPrecision" font-size="110">`, fontFamily)
+	for _, s := range segs {
+		if s.text == "" {
+			continue
+		}
+		fg, shadow := textColors(s.fill)
+		cx, tl := s.textX(), int(math.Round(s.textWidth*10))
+		fmt.Fprintf(buf, `<text aria-hidden="true" x="%d" y="150" fill="%s" fill-opacity=".3" transform="scale(.1)" textLength="%d">%s</text>`, cx, shadow, tl, esc(s.text))
+		fmt.Fprintf(buf, `<text x="%d" y="140" transform="scale(.1)" fill="%s" textLength="%d">%s</text>`, cx, fg, tl, esc(s.text))
+	}
+	buf.WriteString(`</g>`)
+}
+
+// writeFlat and writeSquare leave the svg element open for the logo.
+
+// writeSquare draws flat-square and for-the-badge: no gradient, no rounded
+// corners, no text shadow.
+func writeSquare(buf *bytes.Buffer, title string, width int, segs []segment, tall bool) {
+	height, fontSize, y := 20, 110, 140
+	if tall {
+		height, fontSize, y = 28, 100, 175
+	}
+	openSVG(buf, title, width, height)
+	buf.WriteString(`<g shape-rendering="crispEdges">`)
+	writeRects(buf, segs, height)
+	buf.WriteString(`</g>`)
+	fmt.Fprintf(buf, `<g fill="#fff" text-anchor="middle" font-family="%s" text-rendering="geometricPrecision" font-size="%d">`, fontFamily, fontSize)
+	for _, s := range segs {
+		if s.text == "" {
+			continue
+		}
+		fg, _ := textColors(s.fill)
+		cx, tl := s.textX(), int(math.Round(s.textWidth*10))
+		weight := ""
+		if s.bold {
+			weight = ` font-weight="bold"`
+		}
+		fmt.Fprintf(buf, `<text x="%d" y="%d" transform="scale(.1)" fill="%s" textLength="%d"%s>%s</text>`, cx, y, fg, tl, weight, esc(s.text))
+	}
+	buf.WriteString(`</g>`)
+}
+
+func openSVG(buf *bytes.Buffer, title string, width, height int) {
+	fmt.Fprintf(buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" role="img" aria-label="%s"><title>%s</title>`,
+		width, height, esc(title), esc(title))
+}
+
+func writeRects(buf *bytes.Buffer, segs []segment, height int) {
+	for _, s := range segs {
+		if s.x == 0 {
+			fmt.Fprintf(buf, `<rect width="%d" height="%d" fill="%s"/>`, s.width, height, s.fill)
+			continue
+		}
+		fmt.Fprintf(buf, `<rect x="%d" width="%d" height="%d" fill="%s"/>`, s.x, s.width, height, s.fill)
+	}
+}
+
+// textColors picks dark text on light backgrounds (yellow, lightgrey
+// themes) and white text otherwise, with the matching shadow colour.
+func textColors(fill string) (fg, shadow string) {
+	if brightness(fill) > 0.69 {
+		return "#333", "#ccc"
+	}
+	return "#fff", "#010101"
+}
+
+func esc(s string) string { return html.EscapeString(s) }
@@ -0,0 +1,127 @@
+package badge
+
+import (
+	"encoding/xml"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestRender_Styles(t *testing.T) {
+	t.Parallel()
+
+	b := Badge{Label: "task 00", Message: "ok", Color: "brightgreen"}
+	for _, style := range Styles() {
+		svg, err := Render(b, style)
+		if err != nil {
+			t.Fatalf("%s: %v", style, err)
+		}
+		if err := xml.Unmarshal(svg, new(struct{})); err != nil {
+			t.Errorf("%s: invalid XML: %v\n%s", style, err, svg)
+		}
+		if !strings.Contains(string(svg), `fill="#4c1"`) || !strings.Contains(string(svg), `fill="#555"`) {
+			t.Errorf("%s: missing segment colours:\n%s", style, svg)
+		}
+
+		// ширина бейджа — сумма сегментов
+		m := regexp.MustCompile(`^<svg [^>]*width="(\d+)"`).FindSubmatch(svg)
+		rects := regexp.MustCompile(`<rect (?:x="\d+" )?width="(\d+)" height="\d+" fill="#[0-9a-f]+"/>`).FindAllSubmatch(svg, -1)
+		if m == nil || len(rects) != 2 {
+			t.Fatalf("%s: unexpected layout:\n%s", style, svg)
+		}
+		total, _ := strconv.Atoi(string(m[1]))
+		l, _ := strconv.Atoi(string(rects[0][1]))
+		r, _ := strconv.Atoi(string(rects[1][1]))
+		if l+r != total {
+			t.Errorf("%s: segments %d+%d != width %d", style, l, r, total)
+		}
+	}
+
+	svg, _ := Render(b, ForTheBadge)
+	if !strings.Contains(string(svg), ">TASK 00</text>") || !strings.Contains(string(svg), `height="28"`) {
+		t.Errorf("for-the-badge: want uppercase 28px badge:\n%s", svg)
+	}
+}
+
+func TestRender_Errors(t *testing.T) {
+	t.Parallel()
+
+	if _, err := Render(Badge{Message: "x", Color: "red"}, "plastic"); err == nil {
+		t.Error("plastic: want unsupported style error")
+	}
+	if _, err := Render(Badge{Message: "x", Color: "reddish"}, Flat); err == nil {
+		t.Error("reddish: want unknown colour error")
+	}
+}
+
+func TestRender_Escape(t *testing.T) {
+	t.Parallel()
+
+	svg, err := Render(Badge{Label: "a<b", Message: `"&"`, Color: "ff8800"}, Flat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := xml.Unmarshal(svg, new(struct{})); err != nil {
+		t.Errorf("invalid XML: %v\n%s", err, svg)
+	}
+	if !strings.Contains(string(svg), `fill="#ff8800"`) {
+		t.Errorf("hex colour not applied:\n%s", svg)
+	}
+}
+
+func TestTextWidth(t *testing.T) {
+	t.Parallel()
+
+	if w := TextWidth("ok", 11, false); w < 13 || w > 14 {
+		t.Errorf("ok = %.2f, want ~13.3", w)
+	}
+	if TextWidth("WWW", 11, false) <= TextWidth("iii", 11, false) {
+		t.Error("W should be wider than i")
+	}
+	if TextWidth("ok", 11, true) <= TextWidth("ok", 11, false) {
+		t.Error("bold should be wider")
+	}
+	if w := TextWidth("задача", 11, false); w < 30 {
+		t.Errorf("cyrillic width = %.2f, too narrow", w)
+	}
+}
+
+func TestParseColor(t *testing.T) {
+	t.Parallel()
+
+	for in, want := range map[string]string{
+		"brightgreen": "#4c1",
+		"LightGrey":   "#9f9f9f",
+		"e05d44":      "#e05d44",
+		"#ABC":        "#abc",
+	} {
+		if got, err := ParseColor(in); err != nil || got != want {
+			t.Errorf("ParseColor(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseColor("12345"); err == nil {
+		t.Error("12345: want error")
+	}
+}
+
+func TestRender_Logo(t *testing.T) {
+	t.Parallel()
+
+	plain, _ := Render(Badge{Label: "go", Message: "ok", Color: "green"}, Flat)
+	withLogo, err := Render(Badge{Label: "go", Message: "ok", Color: "green", Logo: "data:image/svg+xml;base64,PHN2Zy8+"}, Flat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(withLogo), `<image x="5" y="3" width="14" height="14"`) {
+		t.Errorf("logo not drawn:\n%s", withLogo)
+	}
+	width := func(svg []byte) int {
+		m := regexp.MustCompile(`^<svg [^>]*width="(\d+)"`).FindSubmatch(svg)
+		n, _ := strconv.Atoi(string(m[1]))
+		return n
+	}
+	if got := width(withLogo) - width(plain); got != logoSize+logoGap {
+		t.Errorf("logo widens badge by %d, want %d", got, logoSize+logoGap)
+	}
+}
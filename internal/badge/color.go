@@ -0,0 +1,59 @@
+package badge
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// namedColors are the shields.io colour names, so configs and URLs written
+// for shields keep working with the local renderer.
+var namedColors = map[string]string{
+	"brightgreen":   "#4c1",
+	"green":         "#97ca00",
+	"yellowgreen":   "#a4a61d",
+	"yellow":        "#dfb317",
+	"orange":        "#fe7d37",
+	"red":           "#e05d44",
+	"blue":          "#007ec6",
+	"grey":          "#555",
+	"gray":          "#555",
+	"lightgrey":     "#9f9f9f",
+	"lightgray":     "#9f9f9f",
+	"success":       "#4c1",
+	"important":     "#fe7d37",
+	"critical":      "#e05d44",
+	"informational": "#007ec6",
+	"inactive":      "#9f9f9f",
+}
+
+// ParseColor resolves a shields colour name or a hex colour with or
+// without "#" ("e05d44", "#4c1") to "#rgb"/"#rrggbb".
+func ParseColor(c string) (string, error) {
+	c = strings.ToLower(strings.TrimSpace(c))
+	if hex, ok := namedColors[c]; ok {
+		return hex, nil
+	}
+	h := strings.TrimPrefix(c, "#")
+	if len(h) == 3 || len(h) == 6 {
+		if _, err := strconv.ParseUint(h, 16, 32); err == nil {
+			return "#" + h, nil
+		}
+	}
+	return "", fmt.Errorf("badge: unknown colour %q", c)
+}
+
+// brightness returns the perceived brightness of a "#rgb"/"#rrggbb"
+// colour in [0,1] (YIQ weights, as shields uses to pick the text colour).
+func brightness(hex string) float64 {
+	h := strings.TrimPrefix(hex, "#")
+	if len(h) == 3 {
+		h = string([]byte{h[0], h[0], h[1], h[1], h[2], h[2]})
+	}
+	v, err := strconv.ParseUint(h, 16, 32)
+	if err != nil || len(h) != 6 {
+		return 0
+	}
+	r, g, b := float64(v>>16&0xff), float64(v>>8&0xff), float64(v&0xff)
+	return (r*299 + g*587 + b*114) / 255000
+}
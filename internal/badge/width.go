@@ -0,0 +1,65 @@
+package badge
+
+import "unicode"
+
+// verdana11 holds advance widths in pixels of printable ASCII (from ' ' to
+// '~') in 11px Verdana, the font shields.io lays badges out with.
+var verdana11 = [...]float64{
+	3.87, 4.33, 5.05, 9.00, 6.99, 11.84, 7.99, 2.95, 4.99, 4.99, 6.99, 9.00, 4.00, 4.99, 4.00, 4.99, // ' ' .. '/'
+	6.99, 6.99, 6.99, 6.99, 6.99, 6.99, 6.99, 6.99, 6.99, 6.99, 4.99, 4.99, 9.00, 9.00, 9.00, 5.99, // '0' .. '?'
+	11.00, 7.52, 7.54, 7.68, 8.48, 6.96, 6.32, 8.53, 8.27, 4.61, 5.00, 7.62, 6.12, 9.27, 8.23, 8.66, // '@' .. 'O'
+	6.63, 8.66, 7.65, 7.52, 6.78, 8.05, 7.52, 10.88, 7.54, 6.77, 7.54, 4.99, 4.99, 4.99, 9.00, 6.99, // 'P' .. '_'
+	6.99, 6.61, 6.85, 5.73, 6.85, 6.55, 3.87, 6.85, 6.96, 3.02, 3.79, 6.51, 3.02, 10.70, 6.96, 6.68, // '`' .. 'o'
+	6.85, 6.85, 4.69, 5.73, 4.33, 6.96, 6.51, 9.00, 6.51, 6.51, 5.78, 6.98, 4.99, 6.98, 9.00, // 'p' .. '~'
+}
+
+// Widths of runes outside the table: Cyrillic and other alphabets are close
+// to Latin letters of the same case; CJK and emoji take a full em.
+const (
+	otherUpperWidth = 7.6
+	otherLowerWidth = 6.8
+	wideWidth       = 11.0
+)
+
+// boldFactor approximates how much wider Verdana Bold is.
+const boldFactor = 1.1
+
+// TextWidth estimates the rendered width in pixels of s in Verdana of the
+// given size. Browsers do not need it to be exact: the SVG pins the text
+// to this width with textLength.
+func TextWidth(s string, size float64, bold bool) float64 {
+	var w float64
+	for _, r := range s {
+		w += runeWidth(r)
+	}
+	w *= size / 11
+	if bold {
+		w *= boldFactor
+	}
+	return w
+}
+
+func runeWidth(r rune) float64 {
+	switch {
+	case r >= ' ' && r <= '~':
+		return verdana11[r-' ']
+	case r < ' ':
+		return 0
+	case r >= 0x1100 && isWide(r):
+		return wideWidth
+	case unicode.IsUpper(r):
+		return otherUpperWidth
+	default:
+		return otherLowerWidth
+	}
+}
+
+func isWide(r rune) bool {
+	return (r >= 0x1100 && r <= 0x115f) || // Hangul Jamo
+		(r >= 0x2e80 && r <= 0xa4cf) || // CJK ... Yi
+		(r >= 0xac00 && r <= 0xd7a3) || // Hangul syllables
+		(r >= 0xf900 && r <= 0xfaff) ||
+		(r >= 0xfe30 && r <= 0xfe4f) ||
+		(r >= 0xff00 && r <= 0xff60) ||
+		(r >= 0x1f300 && r <= 0x1faff) // emoji
+}
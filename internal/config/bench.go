@@ -0,0 +1,64 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BenchmarkLimit bounds one benchmark of a task, e.g. the O(1) Get/Set of
+// an LRU cache as "BenchmarkGet at most 0 allocs/op". Nil limits are not
+// checked; at least one must be set.
+type BenchmarkLimit struct {
+	Name           string   `json:"name"` // as printed by go test without the -P suffix, e.g. BenchmarkGet/size=1000
+	MaxNsPerOp     *float64 `json:"max_ns_per_op,omitempty"`
+	MaxBytesPerOp  *int64   `json:"max_bytes_per_op,omitempty"`
+	MaxAllocsPerOp *int64   `json:"max_allocs_per_op,omitempty"`
+}
+
+// Check returns a description of every limit the measured values exceed.
+// bytes and allocs are nil when the benchmark ran without -benchmem.
+func (l BenchmarkLimit) Check(nsPerOp float64, bytesPerOp, allocsPerOp *int64) []string {
+	var out []string
+	if l.MaxNsPerOp != nil && nsPerOp > *l.MaxNsPerOp {
+		out = append(out, fmt.Sprintf("%.4g ns/op > %.4g", nsPerOp, *l.MaxNsPerOp))
+	}
+	if l.MaxBytesPerOp != nil {
+		switch {
+		case bytesPerOp == nil:
+			out = append(out, "B/op not measured (run with -benchmem)")
+		case *bytesPerOp > *l.MaxBytesPerOp:
+			out = append(out, fmt.Sprintf("%d B/op > %d", *bytesPerOp, *l.MaxBytesPerOp))
+		}
+	}
+	if l.MaxAllocsPerOp != nil {
+		switch {
+		case allocsPerOp == nil:
+			out = append(out, "allocs/op not measured (run with -benchmem)")
+		case *allocsPerOp > *l.MaxAllocsPerOp:
+			out = append(out, fmt.Sprintf("%d allocs/op > %d", *allocsPerOp, *l.MaxAllocsPerOp))
+		}
+	}
+	return out
+}
+
+func (l BenchmarkLimit) validate(path string, names map[string]bool, add func(path, format string, args ...any)) {
+	switch {
+	case !strings.HasPrefix(l.Name, "Benchmark"):
+		add(path+".name", "%q is not a benchmark name (want Benchmark...)", l.Name)
+	case names[l.Name]:
+		add(path+".name", "duplicate benchmark %q", l.Name)
+	}
+	names[l.Name] = true
+	if l.MaxNsPerOp == nil && l.MaxBytesPerOp == nil && l.MaxAllocsPerOp == nil {
+		add(path, "set at least one of max_ns_per_op, max_bytes_per_op, max_allocs_per_op")
+	}
+	if l.MaxNsPerOp != nil && *l.MaxNsPerOp <= 0 {
+		add(path+".max_ns_per_op", "must be positive")
+	}
+	if l.MaxBytesPerOp != nil && *l.MaxBytesPerOp < 0 {
+		add(path+".max_bytes_per_op", "must not be negative")
+	}
+	if l.MaxAllocsPerOp != nil && *l.MaxAllocsPerOp < 0 {
+		add(path+".max_allocs_per_op", "must not be negative")
+	}
+}
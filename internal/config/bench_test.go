@@ -0,0 +1,66 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBenchmarkLimit_Check(t *testing.T) {
+	t.Parallel()
+
+	ns, zero, one := 100.0, int64(0), int64(1)
+	l := BenchmarkLimit{Name: "BenchmarkGet", MaxNsPerOp: &ns, MaxAllocsPerOp: &zero}
+
+	if got := l.Check(80, &one, &zero); len(got) != 0 {
+		t.Errorf("within limits: %v", got)
+	}
+	if got := l.Check(120, nil, &one); len(got) != 2 {
+		t.Errorf("over both limits: %v, want 2 violations", got)
+	}
+	if got := l.Check(80, nil, nil); len(got) != 1 {
+		t.Errorf("without -benchmem: %v, want allocs not measured", got)
+	}
+}
+
+func TestParse_BenchmarkLimits(t *testing.T) {
+	t.Parallel()
+
+	src := `{
+		"version": "1.4.0",
+		"stream": "s",
+		"diff": {"original": {"repo": "o/r", "ref": "master"}},
+		"analytics": {"enabled": false},
+		"grading": {"late_penalty": {"policy": "none"}, "benchmark_share": 1.5},
+		"tasks": [{"id": "06", "benchmarks": [
+			{"name": "BenchmarkGet", "max_allocs_per_op": 0},
+			{"name": "BenchmarkGet", "max_ns_per_op": 100},
+			{"name": "TestGet", "max_bytes_per_op": -1},
+			{"name": "BenchmarkSet"}
+		]}]
+	}`
+	_, err := Parse([]byte(src))
+
+	var verrs ValidationErrors
+	if !errors.As(err, &verrs) {
+		t.Fatalf("Parse error = %v, want ValidationErrors", err)
+	}
+	want := map[string]bool{
+		"grading.benchmark_share":                 true,
+		"tasks[0].benchmarks[1].name":             true,
+		"tasks[0].benchmarks[2].name":             true,
+		"tasks[0].benchmarks[2].max_bytes_per_op": true,
+		"tasks[0].benchmarks[3]":                  true,
+	}
+	got := map[string]bool{}
+	for _, e := range verrs {
+		got[e.Path] = true
+	}
+	for p := range want {
+		if !got[p] {
+			t.Errorf("missing problem for %s in:\n%v", p, err)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("got %d problems, want %d:\n%v", len(got), len(want), err)
+	}
+}
@@ -1,18 +1,41 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"os"
 )
 
+// Load reads the config file, decodes it on top of Default() and validates
+// the result. Unknown keys and invalid values are reported together as a
+// ValidationErrors value.
 func Load(path string) (Config, error) {
 	b, err := os.ReadFile(path)
 	if err != nil {
 		return Config{}, err
 	}
-	var cfg Config
-	if err := json.Unmarshal(b, &cfg); err != nil {
+	cfg, err := Parse(b)
+	if err != nil {
+		return Config{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+func Parse(b []byte) (Config, error) {
+	var raw any
+	if err := json.Unmarshal(b, &raw); err != nil {
 		return Config{}, err
 	}
+	errs := unknownKeys(raw)
+
+	cfg := Default()
+	if err := json.NewDecoder(bytes.NewReader(b)).Decode(&cfg); err != nil {
+		return Config{}, err
+	}
+	errs = append(errs, cfg.validate()...)
+	if len(errs) > 0 {
+		return Config{}, errs
+	}
 	return cfg, nil
 }
@@ -0,0 +1,121 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLoad_RepoConfig(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := Load("../../.etc/config.json")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Diff.Original.Ref != "master" {
+		t.Errorf("diff.original.ref = %q, want %q", cfg.Diff.Original.Ref, "master")
+	}
+	if cfg.Analytics.MaxDiffLines != 5000 {
+		t.Errorf("analytics.max_diff_lines = %d, want 5000", cfg.Analytics.MaxDiffLines)
+	}
+}
+
+func TestParse_CollectsAllProblems(t *testing.T) {
+	t.Parallel()
+
+	src := `{
+		"version": "v1",
+		"stream": "s",
+		"Stream": "s",
+		"diff": {
+			"original": {"repo": "", "branch": "master"},
+			"allow_list": ["tasks/*.go", "/abs", "a/../b"]
+		},
+		"analytics": {"enabled": false}
+	}`
+	_, err := Parse([]byte(src))
+
+	var verrs ValidationErrors
+	if !errors.As(err, &verrs) {
+		t.Fatalf("Parse error = %v, want ValidationErrors", err)
+	}
+	want := map[string]bool{
+		"diff.original.branch": true,
+		"version":              true,
+		"diff.original.repo":   true,
+		"diff.original.ref":    true,
+		"diff.allow_list[1]":   true,
+		"diff.allow_list[2]":   true,
+		"Stream":               true,
+	}
+	got := map[string]bool{}
+	for _, e := range verrs {
+		got[e.Path] = true
+		if e.Path == "Stream" && !strings.Contains(e.Msg, `want "stream"`) {
+			t.Errorf("Stream: %s, want a hint at the exact key", e.Msg)
+		}
+	}
+	for p := range want {
+		if !got[p] {
+			t.Errorf("missing problem for %s in:\n%v", p, err)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("got %d problems, want %d:\n%v", len(got), len(want), err)
+	}
+}
+
+func TestParse_StableOrder(t *testing.T) {
+	t.Parallel()
+
+	src := `{
+		"version": "1.4.0",
+		"stream": "c",
+		"diff": {"original": {"repo": "o/r", "ref": "main"}},
+		"streams": {"c": {"x": 1}, "a": {"x": 1}, "d": {"x": 1}, "b": {"x": 1}},
+		"analytics": {"enabled": false}
+	}`
+	want := []string{"streams.a.x", "streams.b.x", "streams.c.x", "streams.d.x"}
+	// порядок обхода карт случаен: одна удачная попытка ничего не доказывает
+	for range 20 {
+		_, err := Parse([]byte(src))
+		var verrs ValidationErrors
+		if !errors.As(err, &verrs) {
+			t.Fatalf("Parse error = %v, want ValidationErrors", err)
+		}
+		var got []string
+		for _, e := range verrs {
+			got = append(got, e.Path)
+		}
+		if strings.Join(got, " ") != strings.Join(want, " ") {
+			t.Fatalf("problems in order %v, want %v", got, want)
+		}
+	}
+}
+
+func TestCompileGlob(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		pat   string
+		path  string
+		match bool
+	}{
+		{"badges/**", "badges/tasks/task_00.svg", true},
+		{"tasks/*/solution.go", "tasks/task_00/solution.go", true},
+		{"tasks/*/solution.go", "tasks/task_00/sub/solution.go", false},
+		{"tasks/task_0?/solution.go", "tasks/task_07/solution.go", true},
+		{"tasks/", "tasks/task_00/README.md", true},
+		{"go.mod", "go.sum", false},
+	}
+	for _, tt := range tests {
+		re, err := CompileGlob(tt.pat)
+		if err != nil {
+			t.Fatalf("CompileGlob(%q): %v", tt.pat, err)
+		}
+		if got := re.MatchString(tt.path); got != tt.match {
+			t.Errorf("%q matches %q = %v, want %v", tt.pat, tt.path, got, tt.match)
+		}
+	}
+}
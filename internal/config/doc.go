@@ -0,0 +1,7 @@
+// Package config loads .etc/config.json, the file shared by the CI tools.
+//
+// Load reads a single file strictly. LoadLayered stacks, from lowest to
+// highest precedence: built-in defaults, the tracked base file, an untracked
+// override file (.etc/config.local.json by default) and INDUSTRY_*
+// environment variables. Resolved.Sources tells which layer set each field.
+package config
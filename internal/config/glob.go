@@ -0,0 +1,75 @@
+package config
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// ValidateGlob reports whether pat is a usable allow_list pattern.
+// Supported syntax: "*" matches within one path segment, "**" matches
+// across segments, "?" matches one non-slash character and a trailing
+// "/" matches everything inside a directory.
+func ValidateGlob(pat string) error {
+	switch {
+	case strings.TrimSpace(pat) == "":
+		return errors.New("empty pattern")
+	case pat != strings.TrimSpace(pat):
+		return errors.New("pattern has leading or trailing spaces")
+	case strings.HasPrefix(pat, "/"):
+		return errors.New("pattern must be relative to the repo root")
+	case strings.Contains(pat, "\\"):
+		return errors.New("use '/' as path separator")
+	case strings.Contains(pat, "***"):
+		return errors.New("more than two consecutive '*'")
+	}
+	for _, seg := range strings.Split(pat, "/") {
+		if seg == ".." {
+			return errors.New("pattern must not contain '..'")
+		}
+	}
+	return nil
+}
+
+// CompileGlob turns an allow_list pattern into an anchored regexp.
+func CompileGlob(pat string) (*regexp.Regexp, error) {
+	if err := ValidateGlob(pat); err != nil {
+		return nil, err
+	}
+	if strings.HasSuffix(pat, "/") {
+		// "dir/" => всё внутри
+		pat = pat + "**"
+	}
+
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pat); i++ {
+		ch := pat[i]
+
+		if ch == '*' {
+			// ** => match across slashes
+			if i+1 < len(pat) && pat[i+1] == '*' {
+				b.WriteString(".*")
+				i++
+				continue
+			}
+			// * => match within a path segment
+			b.WriteString(`[^/]*`)
+			continue
+		}
+
+		if ch == '?' {
+			b.WriteString(`[^/]`)
+			continue
+		}
+
+		// escape regexp metachars
+		if strings.ContainsRune(`.+()|[]{}^$\/`, rune(ch)) {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(ch)
+	}
+	b.WriteString("$")
+
+	return regexp.Compile(b.String())
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"math"
+	"time"
+)
+
+type Grading struct {
+	LatePenalty LatePenalty `json:"late_penalty"`
+	// BenchmarkShare is the fraction of a task's weight granted only when
+	// its tests pass and every configured benchmark is within limits.
+	// 0 (default) reports benchmarks without affecting the score.
+	BenchmarkShare float64 `json:"benchmark_share,omitempty"`
+}
+
+// Late penalty policies.
+const (
+	PenaltyNone   = "none"    // late work keeps its full score
+	PenaltyFixed  = "fixed"   // Amount is taken off once
+	PenaltyPerDay = "per_day" // Amount is taken off for every started day, up to Max
+	PenaltyZero   = "zero"    // late work scores nothing
+)
+
+// LatePenalty describes how a late submission is scored. Amount and Max are
+// fractions of the task weight.
+type LatePenalty struct {
+	Policy       string  `json:"policy"`
+	GraceMinutes int     `json:"grace_minutes,omitempty"`
+	Amount       float64 `json:"amount,omitempty"`
+	Max          float64 `json:"max,omitempty"` // default 1
+}
+
+func (p LatePenalty) Grace() time.Duration {
+	return time.Duration(p.GraceMinutes) * time.Minute
+}
+
+// Factor returns the multiplier in [0, 1] applied to a score submitted
+// late by the given duration. Work within the grace period is on time, and
+// per_day days start when the grace period ends.
+func (p LatePenalty) Factor(late time.Duration) float64 {
+	if late <= p.Grace() {
+		return 1
+	}
+	maxPenalty := p.Max
+	if maxPenalty == 0 {
+		maxPenalty = 1
+	}
+
+	var penalty float64
+	switch p.Policy {
+	case PenaltyFixed:
+		penalty = p.Amount
+	case PenaltyPerDay:
+		days := math.Ceil((late - p.Grace()).Hours() / 24)
+		penalty = p.Amount * days
+	case PenaltyZero:
+		penalty = 1
+	default:
+		return 1
+	}
+	penalty = math.Min(penalty, maxPenalty)
+	return math.Max(0, 1-penalty)
+}
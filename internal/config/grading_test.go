@@ -0,0 +1,33 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatePenalty_Factor(t *testing.T) {
+	t.Parallel()
+
+	day := 24 * time.Hour
+	tests := []struct {
+		name    string
+		penalty LatePenalty
+		late    time.Duration
+		want    float64
+	}{
+		{"on time", LatePenalty{Policy: PenaltyZero}, -time.Hour, 1},
+		{"none", LatePenalty{Policy: PenaltyNone}, 3 * day, 1},
+		{"grace", LatePenalty{Policy: PenaltyZero, GraceMinutes: 30}, 20 * time.Minute, 1},
+		{"zero", LatePenalty{Policy: PenaltyZero}, time.Minute, 0},
+		{"fixed", LatePenalty{Policy: PenaltyFixed, Amount: 0.25}, 10 * day, 0.75},
+		{"per day started", LatePenalty{Policy: PenaltyPerDay, Amount: 0.1}, day + time.Minute, 0.8},
+		{"per day after grace", LatePenalty{Policy: PenaltyPerDay, Amount: 0.1, GraceMinutes: 60}, day + 30*time.Minute, 0.9},
+		{"per day capped", LatePenalty{Policy: PenaltyPerDay, Amount: 0.1, Max: 0.3}, 10 * day, 0.7},
+	}
+	for _, tt := range tests {
+		got := tt.penalty.Factor(tt.late)
+		if diff := got - tt.want; diff > 1e-9 || diff < -1e-9 {
+			t.Errorf("%s: Factor(%v) = %v, want %v", tt.name, tt.late, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,319 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// EnvPrefix starts every environment variable the loader looks at.
+	EnvPrefix = "INDUSTRY_"
+	// EnvOverride points at an override file when Options.OverridePath is empty.
+	EnvOverride = EnvPrefix + "CONFIG_OVERRIDE"
+	// OverrideName is the untracked file looked up next to the base config.
+	OverrideName = "config.local.json"
+)
+
+// Options select the layers LoadLayered merges.
+type Options struct {
+	// Path is the tracked base config, e.g. .etc/config.json.
+	Path string
+	// OverridePath is an optional partial config. When empty, $INDUSTRY_CONFIG_OVERRIDE
+	// is used, then config.local.json next to Path if it exists.
+	OverridePath string
+	// Environ is usually os.Environ(); nil disables the env layer.
+	Environ []string
+}
+
+// Source names the layer that set a field.
+type Source struct {
+	Layer string `json:"layer"` // default|file|override|env
+	From  string `json:"from,omitempty"`
+}
+
+func (s Source) String() string {
+	if s.From == "" {
+		return s.Layer
+	}
+	return s.Layer + " (" + s.From + ")"
+}
+
+type FieldSource struct {
+	Path   string `json:"path"`
+	Source Source `json:"source"`
+}
+
+// Resolved is a config merged from several layers together with the origin
+// of every leaf value.
+type Resolved struct {
+	Config  Config
+	sources map[string]Source
+}
+
+// Sources reports which layer set each field, sorted by JSON path.
+func (r *Resolved) Sources() []FieldSource {
+	out := make([]FieldSource, 0, len(r.sources))
+	for p, s := range r.sources {
+		out = append(out, FieldSource{Path: p, Source: s})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out
+}
+
+// Source returns the layer that set the value at path.
+func (r *Resolved) Source(path string) (Source, bool) {
+	s, ok := r.sources[path]
+	return s, ok
+}
+
+// LoadLayered builds the effective config. Later layers win:
+//
+//  1. built-in defaults (see Default)
+//  2. the base file (Options.Path)
+//  3. the override file; objects are merged key by key, arrays and
+//     scalars replace the value below
+//  4. INDUSTRY_* environment variables, one per leaf field: the JSON path
+//     upper-cased with dots turned into underscores, e.g.
+//     INDUSTRY_DIFF_ORIGINAL_REF=main. Lists are comma separated.
+//
+// The merged document is then checked exactly like Load does.
+func LoadLayered(opts Options) (*Resolved, error) {
+	r := &Resolved{sources: map[string]Source{}}
+
+	merged, err := toGeneric(Default())
+	if err != nil {
+		return nil, err
+	}
+	markLeaves(merged, "", Source{Layer: "default"}, r.sources)
+
+	base, err := ReadDocument(opts.Path)
+	if err != nil {
+		return nil, err
+	}
+	merged = mergeInto(merged, base, "", Source{Layer: "file", From: opts.Path}, r.sources)
+
+	overridePath, required := opts.OverridePath, true
+	if overridePath == "" {
+		overridePath = lookupEnv(opts.Environ, EnvOverride)
+	}
+	if overridePath == "" && opts.Path != "" {
+		overridePath, required = filepath.Join(filepath.Dir(opts.Path), OverrideName), false
+	}
+	if overridePath != "" {
+		over, err := ReadDocument(overridePath)
+		switch {
+		case err == nil:
+			merged = mergeInto(merged, over, "", Source{Layer: "override", From: overridePath}, r.sources)
+		case !required && errors.Is(err, fs.ErrNotExist):
+		default:
+			return nil, err
+		}
+	}
+
+	if err := applyEnv(merged, opts.Environ, r.sources); err != nil {
+		return nil, err
+	}
+
+	b, err := json.Marshal(merged)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := Parse(b)
+	if err != nil {
+		return nil, err
+	}
+	r.Config = cfg
+	return r, nil
+}
+
+// ReadDocument decodes a config file without interpreting it, keeping
+// numbers as json.Number. Used for migrations and diffs.
+func ReadDocument(path string) (map[string]any, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var obj map[string]any
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&obj); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return obj, nil
+}
+
+func toGeneric(v any) (map[string]any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var obj map[string]any
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// mergeInto merges src over dst and records src as the origin of every leaf
+// it sets.
+func mergeInto(dst, src map[string]any, path string, s Source, sources map[string]Source) map[string]any {
+	if dst == nil {
+		dst = map[string]any{}
+	}
+	for k, v := range src {
+		p := joinPath(path, k)
+		srcObj, srcIsObj := v.(map[string]any)
+		dstObj, dstIsObj := dst[k].(map[string]any)
+		if srcIsObj && dstIsObj {
+			dst[k] = mergeInto(dstObj, srcObj, p, s, sources)
+			continue
+		}
+		dropSources(sources, p)
+		dst[k] = v
+		markLeaves(v, p, s, sources)
+	}
+	return dst
+}
+
+func markLeaves(v any, path string, s Source, sources map[string]Source) {
+	if obj, ok := v.(map[string]any); ok && len(obj) > 0 {
+		for k, el := range obj {
+			markLeaves(el, joinPath(path, k), s, sources)
+		}
+		return
+	}
+	sources[path] = s
+}
+
+func dropSources(sources map[string]Source, path string) {
+	for p := range sources {
+		if p == path || strings.HasPrefix(p, path+".") {
+			delete(sources, p)
+		}
+	}
+}
+
+// EnvVars lists the environment variables that map onto config fields,
+// keyed by variable name with the JSON path as value.
+func EnvVars() map[string]string {
+	out := map[string]string{}
+	for _, l := range leafFields(reflect.TypeOf(Config{}), "") {
+		out[envName(l.path)] = l.path
+	}
+	return out
+}
+
+type leaf struct {
+	path string
+	typ  reflect.Type
+}
+
+func leafFields(t reflect.Type, path string) []leaf {
+	var out []leaf
+	fields := jsonFields(t)
+	names := make([]string, 0, len(fields))
+	for n := range fields {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		f := fields[n]
+		p := joinPath(path, n)
+		switch f.Type.Kind() {
+		case reflect.Struct:
+			out = append(out, leafFields(f.Type, p)...)
+		case reflect.String, reflect.Bool, reflect.Int, reflect.Float64:
+			out = append(out, leaf{path: p, typ: f.Type})
+		case reflect.Slice:
+			if f.Type.Elem().Kind() == reflect.String {
+				out = append(out, leaf{path: p, typ: f.Type})
+			}
+		}
+	}
+	return out
+}
+
+func envName(path string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(path, ".", "_"))
+}
+
+func applyEnv(dst map[string]any, environ []string, sources map[string]Source) error {
+	var errs ValidationErrors
+	for _, l := range leafFields(reflect.TypeOf(Config{}), "") {
+		name := envName(l.path)
+		raw, ok := lookupEnvOK(environ, name)
+		if !ok {
+			continue
+		}
+		v, err := envValue(raw, l.typ)
+		if err != nil {
+			errs = append(errs, FieldError{Path: l.path, Msg: fmt.Sprintf("%s: %v", name, err)})
+			continue
+		}
+		setPath(dst, strings.Split(l.path, "."), v)
+		sources[l.path] = Source{Layer: "env", From: name}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func envValue(raw string, t reflect.Type) (any, error) {
+	switch t.Kind() {
+	case reflect.Bool:
+		return strconv.ParseBool(strings.TrimSpace(raw))
+	case reflect.Int:
+		return strconv.Atoi(strings.TrimSpace(raw))
+	case reflect.Float64:
+		return strconv.ParseFloat(strings.TrimSpace(raw), 64)
+	case reflect.Slice:
+		list := []any{}
+		for _, s := range strings.Split(raw, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				list = append(list, s)
+			}
+		}
+		return list, nil
+	default:
+		return raw, nil
+	}
+}
+
+func setPath(obj map[string]any, keys []string, v any) {
+	for _, k := range keys[:len(keys)-1] {
+		next, ok := obj[k].(map[string]any)
+		if !ok {
+			next = map[string]any{}
+			obj[k] = next
+		}
+		obj = next
+	}
+	obj[keys[len(keys)-1]] = v
+}
+
+func lookupEnv(environ []string, name string) string {
+	v, _ := lookupEnvOK(environ, name)
+	return v
+}
+
+func lookupEnvOK(environ []string, name string) (string, bool) {
+	for i := len(environ) - 1; i >= 0; i-- {
+		k, v, ok := strings.Cut(environ[i], "=")
+		if ok && k == name {
+			return v, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadLayered_Precedence(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	base, err := os.ReadFile("../../.etc/config.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	basePath := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(basePath, base, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	override := `{"diff": {"original": {"ref": "dev"}}, "tests": {"ignore_packages": ["x"]}}`
+	if err := os.WriteFile(filepath.Join(dir, OverrideName), []byte(override), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := LoadLayered(Options{
+		Path: basePath,
+		Environ: []string{
+			"INDUSTRY_TESTS_IGNORE_PACKAGES=a, b",
+			"INDUSTRY_ANALYTICS_ENABLED=false",
+		},
+	})
+	if err != nil {
+		t.Fatalf("LoadLayered: %v", err)
+	}
+
+	cfg := r.Config
+	if cfg.Diff.Original.Ref != "dev" {
+		t.Errorf("ref = %q, want dev", cfg.Diff.Original.Ref)
+	}
+	if cfg.Diff.Original.Repo != "ippaveln/industry_backend_go_spring_2026" {
+		t.Errorf("repo = %q, want value from base file", cfg.Diff.Original.Repo)
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(cfg.Tests.IgnorePackages, want) {
+		t.Errorf("ignore_packages = %v, want %v", cfg.Tests.IgnorePackages, want)
+	}
+	if cfg.Analytics.Enabled {
+		t.Error("analytics.enabled = true, want false from env")
+	}
+
+	wantSources := map[string]string{
+		"diff.original.repo":       "file",
+		"diff.original.ref":        "override",
+		"tests.ignore_packages":    "env",
+		"analytics.enabled":        "env",
+		"analytics.max_diff_lines": "file",
+	}
+	for p, layer := range wantSources {
+		s, ok := r.Source(p)
+		if !ok || s.Layer != layer {
+			t.Errorf("source of %s = %v, want %s", p, s, layer)
+		}
+	}
+}
+
+func TestLoadLayered_MissingExplicitOverride(t *testing.T) {
+	t.Parallel()
+
+	_, err := LoadLayered(Options{Path: "../../.etc/config.json", OverridePath: filepath.Join(t.TempDir(), "nope.json")})
+	if err == nil {
+		t.Fatal("expected error for missing explicit override file")
+	}
+}
@@ -0,0 +1,157 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SchemaVersion is the config format the Config struct describes.
+const SchemaVersion = "1.4.0"
+
+// MinVersion is the oldest schema Migrate upgrades from: the first one
+// the tools were released with.
+const MinVersion = "1.0.0"
+
+// Migration is a format change that needs existing documents rewritten.
+// Version is the schema that introduced it. Versions that only added
+// optional fields need no migration: Migrate just updates "version".
+type Migration struct {
+	Version string
+	Desc    string
+	Apply   func(doc map[string]any) error
+}
+
+var migrations []Migration
+
+// Register adds a migration; each schema version may have only one.
+func Register(m Migration) {
+	if !semverRe.MatchString(m.Version) {
+		panic("config: migration version " + m.Version + " is not a semantic version")
+	}
+	for _, o := range migrations {
+		if CompareVersions(o.Version, m.Version) == 0 {
+			panic("config: duplicate migration to " + m.Version)
+		}
+	}
+	migrations = append(migrations, m)
+	sort.Slice(migrations, func(i, j int) bool { return CompareVersions(migrations[i].Version, migrations[j].Version) < 0 })
+}
+
+// Migrations returns the registered migrations ordered by version.
+func Migrations() []Migration {
+	return slices.Clone(migrations)
+}
+
+// Migrate upgrades doc to version to: it applies the migrations of every
+// schema after the document's up to and including to, then sets
+// "version". It returns the migrations it applied.
+func Migrate(doc map[string]any, to string) ([]Migration, error) {
+	from, _ := doc["version"].(string)
+	switch {
+	case from == "":
+		return nil, errors.New("document has no version")
+	case !semverRe.MatchString(to):
+		return nil, fmt.Errorf("target %q is not a semantic version", to)
+	case CompareVersions(from, to) > 0:
+		return nil, fmt.Errorf("cannot downgrade from %s to %s", from, to)
+	case CompareVersions(from, MinVersion) < 0:
+		return nil, fmt.Errorf("no migration path from %s: the oldest supported schema is %s", from, MinVersion)
+	case CompareVersions(to, SchemaVersion) > 0:
+		return nil, fmt.Errorf("schema %s is newer than this tooling supports (%s)", to, SchemaVersion)
+	}
+
+	var applied []Migration
+	for _, m := range migrations {
+		if CompareVersions(m.Version, from) <= 0 || CompareVersions(m.Version, to) > 0 {
+			continue
+		}
+		if err := m.Apply(doc); err != nil {
+			return applied, fmt.Errorf("migrate to %s: %w", m.Version, err)
+		}
+		applied = append(applied, m)
+	}
+	doc["version"] = to
+	return applied, nil
+}
+
+func init() {
+	Register(Migration{
+		Version: "1.2.0",
+		Desc:    "move tasks/task_NN/solution.go allow_list entries into the tasks registry",
+		Apply:   migrateTaskRegistry,
+	})
+}
+
+var taskAllowRe = regexp.MustCompile(`^tasks/task_(\d+)/solution\.go$`)
+
+func migrateTaskRegistry(doc map[string]any) error {
+	diff, ok := lookupObject(doc, "diff")
+	if !ok {
+		return nil
+	}
+	list, _ := diff["allow_list"].([]any)
+	keep := []any{}
+	tasks, _ := doc["tasks"].([]any)
+	for _, v := range list {
+		pat, _ := v.(string)
+		m := taskAllowRe.FindStringSubmatch(pat)
+		if m == nil {
+			keep = append(keep, v)
+			continue
+		}
+		tasks = append(tasks, map[string]any{
+			"id":      m[1],
+			"package": "tasks/task_" + m[1],
+			"files":   []any{"solution.go"},
+		})
+	}
+	diff["allow_list"] = keep
+	if len(tasks) > 0 {
+		doc["tasks"] = tasks
+	}
+	return nil
+}
+
+func lookupObject(doc map[string]any, keys ...string) (map[string]any, bool) {
+	cur := doc
+	for _, k := range keys {
+		next, ok := cur[k].(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		cur = next
+	}
+	return cur, true
+}
+
+// CompareVersions compares MAJOR.MINOR.PATCH of two semantic versions
+// numerically, so 1.10.0 is newer than 1.9.0; pre-release and build
+// suffixes are ignored. Malformed parts count as 0.
+func CompareVersions(a, b string) int {
+	pa, pb := versionParts(a), versionParts(b)
+	for i := range pa {
+		if pa[i] != pb[i] {
+			if pa[i] < pb[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func versionParts(v string) [3]int {
+	var out [3]int
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	for i, s := range strings.SplitN(v, ".", 3) {
+		out[i], _ = strconv.Atoi(s)
+	}
+	return out
+}
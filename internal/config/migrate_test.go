@@ -0,0 +1,93 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMigrate_TaskRegistry(t *testing.T) {
+	t.Parallel()
+
+	doc := map[string]any{}
+	src := `{"version": "1.1.0", "diff": {"original": {"repo": "o/r", "ref": "master"},
+		"allow_list": ["badges/**", "tasks/task_03/solution.go"]}}`
+	if err := json.Unmarshal([]byte(src), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err := Migrate(doc, SchemaVersion)
+	if err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	if len(applied) != 1 || applied[0].Version != "1.2.0" {
+		t.Fatalf("applied = %+v, want the 1.2.0 migration only", applied)
+	}
+	diff, _ := lookupObject(doc, "diff")
+	if list, _ := diff["allow_list"].([]any); len(list) != 1 || list[0] != "badges/**" {
+		t.Errorf("allow_list = %v, want [badges/**]", diff["allow_list"])
+	}
+	tasks, _ := doc["tasks"].([]any)
+	if len(tasks) != 1 || tasks[0].(map[string]any)["package"] != "tasks/task_03" {
+		t.Errorf("tasks = %v, want task 03", doc["tasks"])
+	}
+	if doc["version"] != SchemaVersion {
+		t.Errorf("version = %v, want %s", doc["version"], SchemaVersion)
+	}
+}
+
+func TestMigrate_VersionOnly(t *testing.T) {
+	t.Parallel()
+
+	// 1.3.0 и 1.4.0 добавили только необязательные поля: документ не меняется,
+	// кроме версии
+	doc := map[string]any{"version": "1.2.0", "stream": "s"}
+	applied, err := Migrate(doc, SchemaVersion)
+	if err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	if len(applied) != 0 {
+		t.Errorf("applied = %+v, want none", applied)
+	}
+	if doc["version"] != SchemaVersion || doc["stream"] != "s" || len(doc) != 2 {
+		t.Errorf("doc = %v, want only the version changed", doc)
+	}
+}
+
+func TestMigrate_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		from string
+		to   string
+	}{
+		{"downgrade", SchemaVersion, "1.0.0"},
+		{"older than supported", "0.9.0", SchemaVersion},
+		{"newer than supported", "1.0.0", "9.0.0"},
+		{"bad target", "1.0.0", "latest"},
+	}
+	for _, tt := range tests {
+		if _, err := Migrate(map[string]any{"version": tt.from}, tt.to); err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		}
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.10.0", "1.9.0", 1},
+		{"1.9.0", "1.10.0", -1},
+		{"1.4.0", "1.4.0+build", 0},
+		{"2.0.0", "10.0.0", -1},
+	}
+	for _, tt := range tests {
+		if got := CompareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Profile is the effective view of the config for one stream.
+type Profile struct {
+	Name string
+	// Tasks are the enabled registry tasks of the stream, in registry order.
+	Tasks    []Task
+	Original Original
+	// AllowList is diff.allow_list (or the stream's) plus the editable files
+	// of every task in Tasks.
+	AllowList      []string
+	IgnorePackages []string
+	Deadlines      []Deadline
+	Grading        Grading
+
+	registry bool     // config has a tasks section
+	taskIDs  []string // stream filter for configs without one
+}
+
+// Profile resolves the stream called name, or the default "stream" when
+// name is empty. A config without a streams section yields the top-level
+// values under the default name.
+func (c Config) Profile(name string) (Profile, error) {
+	if name == "" {
+		name = c.Stream
+	}
+	p := Profile{
+		Name:           name,
+		Original:       c.Diff.Original,
+		AllowList:      c.Diff.AllowList,
+		IgnorePackages: c.Tests.IgnorePackages,
+		Grading:        c.Grading,
+	}
+
+	s, ok := c.Streams[name]
+	if !ok && (len(c.Streams) > 0 || name != c.Stream) {
+		return Profile{}, fmt.Errorf("unknown stream %q (known: %v)", name, c.StreamNames())
+	}
+
+	p.taskIDs = s.Tasks
+	if s.Original != nil {
+		p.Original = *s.Original
+	}
+	if s.AllowList != nil {
+		p.AllowList = s.AllowList
+	}
+	if s.IgnorePackages != nil {
+		p.IgnorePackages = s.IgnorePackages
+	}
+	p.Deadlines = s.Deadlines
+	if s.Grading != nil {
+		p.Grading = *s.Grading
+	}
+
+	p.registry = len(c.Tasks) > 0
+	allow := slices.Clone(p.AllowList)
+	for _, t := range c.Tasks {
+		if !t.IsEnabled() || (len(p.taskIDs) > 0 && !slices.Contains(p.taskIDs, t.ID)) {
+			continue
+		}
+		p.Tasks = append(p.Tasks, t)
+		allow = append(allow, t.EditableFiles()...)
+	}
+	p.AllowList = allow
+	return p, nil
+}
+
+// StreamNames returns the configured stream names in sorted order.
+func (c Config) StreamNames() []string {
+	names := make([]string, 0, len(c.Streams))
+	for n := range c.Streams {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// HasTask reports whether the task with the given id belongs to the stream.
+func (p Profile) HasTask(id string) bool {
+	if !p.registry {
+		return len(p.taskIDs) == 0 || slices.Contains(p.taskIDs, id)
+	}
+	_, ok := p.Task(id)
+	return ok
+}
+
+func (p Profile) Task(id string) (Task, bool) {
+	for _, t := range p.Tasks {
+		if t.ID == id {
+			return t, true
+		}
+	}
+	return Task{}, false
+}
+
+// DeadlineFor returns the stream deadline the task is due at.
+func (p Profile) DeadlineFor(t Task) (Deadline, bool) {
+	for _, d := range p.Deadlines {
+		if d.Name == t.Deadline {
+			return d, true
+		}
+	}
+	return Deadline{}, false
+}
+
+// TaskForPackage finds the task whose package is importPath.
+func (p Profile) TaskForPackage(importPath string) (Task, bool) {
+	for _, t := range p.Tasks {
+		if t.MatchesPackage(importPath) {
+			return t, true
+		}
+	}
+	return Task{}, false
+}
+
+// Reports tells whether importPath belongs in test reports: with a task
+// registry only task packages do, otherwise everything not ignored.
+func (p Profile) Reports(importPath string) bool {
+	if slices.ContainsFunc(p.IgnorePackages, func(s string) bool { return strings.TrimSpace(s) == importPath }) {
+		return false
+	}
+	if !p.registry {
+		id, ok := TaskID(importPath)
+		return !ok || p.HasTask(id)
+	}
+	_, ok := p.TaskForPackage(importPath)
+	return ok
+}
+
+var taskPkgRe = regexp.MustCompile(`(?:^|/)task_(\d+)$`)
+
+// TaskID extracts "NN" from a task package path such as
+// industry_backend_go/tasks/task_NN.
+func TaskID(pkg string) (string, bool) {
+	m := taskPkgRe.FindStringSubmatch(pkg)
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestProfile(t *testing.T) {
+	t.Parallel()
+
+	cfg := Default()
+	cfg.Stream = "spring"
+	cfg.Diff.Original = Original{Repo: "o/spring", Ref: "master"}
+	cfg.Diff.AllowList = []string{"tasks/*/solution.go"}
+	cfg.Streams = map[string]Stream{
+		"spring": {},
+		"autumn": {
+			Tasks:    []string{"00", "01"},
+			Original: &Original{Repo: "o/autumn", Ref: "main"},
+		},
+	}
+
+	spring, err := cfg.Profile("")
+	if err != nil {
+		t.Fatalf("Profile(\"\"): %v", err)
+	}
+	if spring.Name != "spring" || spring.Original.Repo != "o/spring" || !spring.HasTask("07") {
+		t.Errorf("default profile = %+v", spring)
+	}
+
+	autumn, err := cfg.Profile("autumn")
+	if err != nil {
+		t.Fatalf("Profile(autumn): %v", err)
+	}
+	if autumn.Original.Repo != "o/autumn" {
+		t.Errorf("autumn repo = %q", autumn.Original.Repo)
+	}
+	if !reflect.DeepEqual(autumn.AllowList, cfg.Diff.AllowList) {
+		t.Errorf("autumn allow_list = %v, want inherited %v", autumn.AllowList, cfg.Diff.AllowList)
+	}
+	if autumn.HasTask("07") || !autumn.HasTask("01") {
+		t.Errorf("autumn tasks = %v", autumn.Tasks)
+	}
+
+	if _, err := cfg.Profile("winter"); err == nil {
+		t.Error("expected error for unknown stream")
+	}
+}
+
+func TestProfile_TaskRegistry(t *testing.T) {
+	t.Parallel()
+
+	off := false
+	cfg := Default()
+	cfg.Version = SchemaVersion
+	cfg.Stream = "spring"
+	cfg.Diff.Original = Original{Repo: "o/r", Ref: "master"}
+	cfg.Analytics.Enabled = false
+	cfg.Diff.AllowList = []string{"badges/**"}
+	cfg.Tests.IgnorePackages = []string{" m/tasks/task_01 "}
+	cfg.Tasks = []Task{
+		{ID: "00"},
+		{ID: "01", Files: []string{"solution.go", "helpers.go"}},
+		{ID: "02", Enabled: &off},
+		{ID: "03", Package: "extra/lru"},
+	}
+	cfg.Streams = map[string]Stream{
+		"spring": {},
+		"autumn": {Tasks: []string{"00", "03"}},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	spring, err := cfg.Profile("spring")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantAllow := []string{
+		"badges/**",
+		"tasks/task_00/solution.go",
+		"tasks/task_01/solution.go",
+		"tasks/task_01/helpers.go",
+		"extra/lru/solution.go",
+	}
+	if !reflect.DeepEqual(spring.AllowList, wantAllow) {
+		t.Errorf("allow_list = %v, want %v", spring.AllowList, wantAllow)
+	}
+	if spring.HasTask("02") {
+		t.Error("disabled task 02 is part of the profile")
+	}
+
+	reports := map[string]bool{
+		"m/tasks/task_00":  true,
+		"m/tasks/task_01":  false, // ignored
+		"m/tasks/task_02":  false, // disabled
+		"m/extra/lru":      true,
+		"m/cmd/testreport": false,
+	}
+	for pkg, want := range reports {
+		if got := spring.Reports(pkg); got != want {
+			t.Errorf("Reports(%s) = %v, want %v", pkg, got, want)
+		}
+	}
+
+	autumn, err := cfg.Profile("autumn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(autumn.Tasks) != 2 || autumn.HasTask("01") {
+		t.Errorf("autumn tasks = %+v", autumn.Tasks)
+	}
+}
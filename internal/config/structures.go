@@ -1,18 +1,74 @@
 package config
 
+import "time"
+
 type Config struct {
 	Version string `json:"version"`
 	Stream  string `json:"stream"`
 
-	Tests struct {
-		IgnorePackages []string `json:"ignore_packages"`
-	} `json:"tests"`
-
-	Diff struct {
-		Original struct {
-			Repo   string `json:"repo"`
-			Branch string `json:"branch"`
-		} `json:"original"`
-		AllowList []string `json:"allow_list"`
-	} `json:"diff"`
+	Tests     Tests     `json:"tests"`
+	Diff      Diff      `json:"diff"`
+	Analytics Analytics `json:"analytics"`
+	Grading   Grading   `json:"grading"`
+
+	// Tasks is the task registry. Tools derive allow-lists, reported
+	// packages and badges from it.
+	Tasks []Task `json:"tasks,omitempty"`
+
+	// Streams holds per-cohort profiles; "stream" selects the default one.
+	Streams map[string]Stream `json:"streams,omitempty"`
+}
+
+type Tests struct {
+	IgnorePackages []string `json:"ignore_packages"`
+}
+
+type Diff struct {
+	Original  Original `json:"original"`
+	AllowList []string `json:"allow_list"`
+}
+
+// Original describes the baseline repository that forks are compared against.
+type Original struct {
+	Repo string `json:"repo"`
+	Ref  string `json:"ref"`
+}
+
+// Stream is a cohort profile. Every set field replaces the matching
+// top-level value; empty fields inherit it.
+type Stream struct {
+	Tasks          []string   `json:"tasks,omitempty"` // task ids, e.g. "00"; empty means all
+	Original       *Original  `json:"original,omitempty"`
+	AllowList      []string   `json:"allow_list,omitempty"`
+	IgnorePackages []string   `json:"ignore_packages,omitempty"`
+	Deadlines      []Deadline `json:"deadlines,omitempty"`
+	Grading        *Grading   `json:"grading,omitempty"`
+}
+
+type Deadline struct {
+	Name  string    `json:"name"`
+	Title string    `json:"title,omitempty"`
+	At    time.Time `json:"at"`
+}
+
+type Analytics struct {
+	Enabled        bool   `json:"enabled"`
+	URL            string `json:"url"`
+	TimeoutSeconds int    `json:"timeout_seconds"`
+	MaxDiffLines   int    `json:"max_diff_lines"`
+}
+
+// Default returns the values used for keys missing from the file.
+// They match the fallbacks the CI workflow applies with jq.
+func Default() Config {
+	return Config{
+		Analytics: Analytics{
+			Enabled:        true,
+			TimeoutSeconds: 8,
+			MaxDiffLines:   5000,
+		},
+		Grading: Grading{
+			LatePenalty: LatePenalty{Policy: PenaltyNone},
+		},
+	}
 }
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"path"
+	"strings"
+)
+
+// Task is one entry of the task registry. Only ID is required; the other
+// fields fall back to the layout every task in tasks/ follows.
+type Task struct {
+	ID       string   `json:"id"`
+	Title    string   `json:"title,omitempty"`
+	Package  string   `json:"package,omitempty"`  // dir from repo root, default tasks/task_<id>
+	Files    []string `json:"files,omitempty"`    // editable files inside Package, default solution.go
+	Deadline string   `json:"deadline,omitempty"` // name of a stream deadline
+	Weight   float64  `json:"weight,omitempty"`   // default 1
+	Enabled  *bool    `json:"enabled,omitempty"`  // default true
+
+	// Benchmarks are performance requirements checked by testreport -bench.
+	Benchmarks []BenchmarkLimit `json:"benchmarks,omitempty"`
+}
+
+func (t Task) Dir() string {
+	if t.Package != "" {
+		return path.Clean(t.Package)
+	}
+	return "tasks/task_" + t.ID
+}
+
+// EditableFiles returns the allow_list patterns for the task, relative to
+// the repo root.
+func (t Task) EditableFiles() []string {
+	files := t.Files
+	if len(files) == 0 {
+		files = []string{"solution.go"}
+	}
+	out := make([]string, 0, len(files))
+	for _, f := range files {
+		out = append(out, t.Dir()+"/"+f)
+	}
+	return out
+}
+
+func (t Task) IsEnabled() bool {
+	return t.Enabled == nil || *t.Enabled
+}
+
+func (t Task) EffectiveWeight() float64 {
+	if t.Weight == 0 {
+		return 1
+	}
+	return t.Weight
+}
+
+// MatchesPackage reports whether importPath (as printed by go list) is the
+// package of the task.
+func (t Task) MatchesPackage(importPath string) bool {
+	dir := t.Dir()
+	return importPath == dir || strings.HasSuffix(importPath, "/"+dir)
+}
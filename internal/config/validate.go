@@ -0,0 +1,342 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FieldError is a single problem found in the config, addressed by its JSON
+// path (e.g. "diff.allow_list[3]").
+type FieldError struct {
+	Path string
+	Msg  string
+}
+
+func (e FieldError) Error() string {
+	if e.Path == "" {
+		return e.Msg
+	}
+	return e.Path + ": " + e.Msg
+}
+
+// ValidationErrors collects every problem found in a config instead of
+// stopping at the first one.
+type ValidationErrors []FieldError
+
+func (v ValidationErrors) Error() string {
+	lines := make([]string, 0, len(v)+1)
+	lines = append(lines, fmt.Sprintf("invalid config: %d problem(s)", len(v)))
+	for _, e := range v {
+		lines = append(lines, "  "+e.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+var semverRe = regexp.MustCompile(`^(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?$`)
+
+var repoRe = regexp.MustCompile(`^[A-Za-z0-9_.-]+/[A-Za-z0-9_.-]+$`)
+
+// Validate checks values that decode fine but make no sense for the tools.
+// It returns nil or a ValidationErrors.
+func (c Config) Validate() error {
+	if errs := c.validate(); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (c Config) validate() ValidationErrors {
+	var errs ValidationErrors
+	add := func(path, format string, args ...any) {
+		errs = append(errs, FieldError{Path: path, Msg: fmt.Sprintf(format, args...)})
+	}
+
+	if c.Version == "" {
+		add("version", "must not be empty")
+	} else if !semverRe.MatchString(c.Version) {
+		add("version", "%q is not a semantic version (MAJOR.MINOR.PATCH)", c.Version)
+	} else if versionParts(c.Version)[0] < versionParts(SchemaVersion)[0] {
+		add("version", "schema %s is older than %s, run `go run ./cmd/configctl migrate`", c.Version, SchemaVersion)
+	} else if versionParts(c.Version)[0] > versionParts(SchemaVersion)[0] {
+		add("version", "schema %s is newer than this tooling supports (%s)", c.Version, SchemaVersion)
+	}
+	if strings.TrimSpace(c.Stream) == "" {
+		add("stream", "must not be empty")
+	}
+
+	for i, p := range c.Tests.IgnorePackages {
+		if strings.TrimSpace(p) == "" {
+			add(fmt.Sprintf("tests.ignore_packages[%d]", i), "must not be empty")
+		}
+	}
+
+	switch {
+	case c.Diff.Original.Repo == "":
+		add("diff.original.repo", "must not be empty")
+	case !repoRe.MatchString(c.Diff.Original.Repo):
+		add("diff.original.repo", "%q is not in owner/name form", c.Diff.Original.Repo)
+	}
+	if strings.TrimSpace(c.Diff.Original.Ref) == "" {
+		add("diff.original.ref", "must not be empty")
+	}
+	for i, pat := range c.Diff.AllowList {
+		if err := ValidateGlob(pat); err != nil {
+			add(fmt.Sprintf("diff.allow_list[%d]", i), "%v", err)
+		}
+	}
+
+	ids := map[string]bool{}
+	for i, t := range c.Tasks {
+		t.validate(fmt.Sprintf("tasks[%d]", i), ids, add)
+	}
+
+	if len(c.Streams) > 0 {
+		if _, ok := c.Streams[c.Stream]; !ok && c.Stream != "" {
+			add("stream", "%q is not defined in streams", c.Stream)
+		}
+	}
+	for _, name := range c.StreamNames() {
+		c.Streams[name].validate("streams."+name, add)
+	}
+	c.validateTaskRefs(ids, add)
+
+	c.Grading.validate("grading", add)
+
+	if c.Analytics.Enabled {
+		if c.Analytics.URL == "" {
+			add("analytics.url", "must not be empty when analytics is enabled")
+		} else if u, err := url.Parse(c.Analytics.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add("analytics.url", "%q is not an absolute http(s) URL", c.Analytics.URL)
+		}
+	}
+	if c.Analytics.TimeoutSeconds <= 0 {
+		add("analytics.timeout_seconds", "must be positive, got %d", c.Analytics.TimeoutSeconds)
+	}
+	if c.Analytics.MaxDiffLines <= 0 {
+		add("analytics.max_diff_lines", "must be positive, got %d", c.Analytics.MaxDiffLines)
+	}
+
+	return errs
+}
+
+func (g Grading) validate(path string, add func(path, format string, args ...any)) {
+	lp := g.LatePenalty
+	switch lp.Policy {
+	case PenaltyNone, PenaltyFixed, PenaltyPerDay, PenaltyZero:
+	default:
+		add(path+".late_penalty.policy", "unknown policy %q (want %s, %s, %s or %s)",
+			lp.Policy, PenaltyNone, PenaltyFixed, PenaltyPerDay, PenaltyZero)
+	}
+	if lp.GraceMinutes < 0 {
+		add(path+".late_penalty.grace_minutes", "must not be negative")
+	}
+	if lp.Amount < 0 || lp.Amount > 1 {
+		add(path+".late_penalty.amount", "must be between 0 and 1, got %v", lp.Amount)
+	}
+	if lp.Max < 0 || lp.Max > 1 {
+		add(path+".late_penalty.max", "must be between 0 and 1, got %v", lp.Max)
+	}
+	if g.BenchmarkShare < 0 || g.BenchmarkShare > 1 {
+		add(path+".benchmark_share", "must be between 0 and 1, got %v", g.BenchmarkShare)
+	}
+}
+
+func (t Task) validate(path string, ids map[string]bool, add func(path, format string, args ...any)) {
+	switch {
+	case strings.TrimSpace(t.ID) == "":
+		add(path+".id", "must not be empty")
+	case ids[t.ID]:
+		add(path+".id", "duplicate task id %q", t.ID)
+	}
+	ids[t.ID] = true
+	if t.Package != "" {
+		if err := ValidateGlob(t.Package); err != nil || strings.ContainsAny(t.Package, "*?") {
+			add(path+".package", "%q is not a relative directory", t.Package)
+		}
+	}
+	for i, f := range t.Files {
+		if err := ValidateGlob(f); err != nil {
+			add(fmt.Sprintf("%s.files[%d]", path, i), "%v", err)
+		}
+	}
+	if t.Weight < 0 {
+		add(path+".weight", "must not be negative")
+	}
+	names := map[string]bool{}
+	for i, b := range t.Benchmarks {
+		b.validate(fmt.Sprintf("%s.benchmarks[%d]", path, i), names, add)
+	}
+}
+
+// validateTaskRefs checks that streams only list registered tasks and that
+// task deadlines exist in every stream the task belongs to.
+func (c Config) validateTaskRefs(ids map[string]bool, add func(path, format string, args ...any)) {
+	if len(c.Tasks) > 0 {
+		for _, name := range c.StreamNames() {
+			for i, id := range c.Streams[name].Tasks {
+				if !ids[id] {
+					add(fmt.Sprintf("streams.%s.tasks[%d]", name, i), "unknown task %q", id)
+				}
+			}
+		}
+	}
+
+	for i, t := range c.Tasks {
+		if t.Deadline == "" {
+			continue
+		}
+		if len(c.Streams) == 0 {
+			add(fmt.Sprintf("tasks[%d].deadline", i), "%q refers to a deadline, but no streams define any", t.Deadline)
+			continue
+		}
+		for _, name := range c.StreamNames() {
+			s := c.Streams[name]
+			if len(s.Tasks) > 0 && !slices.Contains(s.Tasks, t.ID) {
+				continue
+			}
+			if !slices.ContainsFunc(s.Deadlines, func(d Deadline) bool { return d.Name == t.Deadline }) {
+				add(fmt.Sprintf("tasks[%d].deadline", i), "deadline %q is not defined in streams.%s", t.Deadline, name)
+			}
+		}
+	}
+}
+
+func (s Stream) validate(path string, add func(path, format string, args ...any)) {
+	for i, id := range s.Tasks {
+		if strings.TrimSpace(id) == "" {
+			add(fmt.Sprintf("%s.tasks[%d]", path, i), "must not be empty")
+		}
+	}
+	if s.Original != nil {
+		if !repoRe.MatchString(s.Original.Repo) {
+			add(path+".original.repo", "%q is not in owner/name form", s.Original.Repo)
+		}
+		if strings.TrimSpace(s.Original.Ref) == "" {
+			add(path+".original.ref", "must not be empty")
+		}
+	}
+	for i, pat := range s.AllowList {
+		if err := ValidateGlob(pat); err != nil {
+			add(fmt.Sprintf("%s.allow_list[%d]", path, i), "%v", err)
+		}
+	}
+	for i, p := range s.IgnorePackages {
+		if strings.TrimSpace(p) == "" {
+			add(fmt.Sprintf("%s.ignore_packages[%d]", path, i), "must not be empty")
+		}
+	}
+	if s.Grading != nil {
+		s.Grading.validate(path+".grading", add)
+	}
+	seen := map[string]bool{}
+	for i, d := range s.Deadlines {
+		dp := fmt.Sprintf("%s.deadlines[%d]", path, i)
+		switch {
+		case d.Name == "":
+			add(dp+".name", "must not be empty")
+		case seen[d.Name]:
+			add(dp+".name", "duplicate deadline %q", d.Name)
+		}
+		seen[d.Name] = true
+		if d.At.IsZero() {
+			add(dp+".at", "must be an RFC 3339 timestamp")
+		}
+	}
+}
+
+// unknownKeys walks the generic JSON value alongside the Config type and
+// reports every key that has no matching field.
+func unknownKeys(raw any) ValidationErrors {
+	var errs ValidationErrors
+	walkUnknown(raw, reflect.TypeOf(Config{}), "", &errs)
+	return errs
+}
+
+func walkUnknown(v any, t reflect.Type, path string, errs *ValidationErrors) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return
+		}
+		fields := jsonFields(t)
+		for _, k := range sortedKeys(obj) {
+			f, ok := fields[k]
+			if !ok {
+				msg := "unknown key"
+				// encoding/json сопоставил бы ключ без учёта регистра, а слияние
+				// слоёв — нет; требуем точное имя, чтобы слои не разъезжались
+				for name := range fields {
+					if strings.EqualFold(name, k) {
+						msg = fmt.Sprintf("unknown key (keys are case-sensitive, want %q)", name)
+						break
+					}
+				}
+				*errs = append(*errs, FieldError{Path: joinPath(path, k), Msg: msg})
+				continue
+			}
+			walkUnknown(obj[k], f.Type, joinPath(path, k), errs)
+		}
+	case reflect.Slice:
+		arr, ok := v.([]any)
+		if !ok {
+			return
+		}
+		for i, el := range arr {
+			walkUnknown(el, t.Elem(), path+"["+strconv.Itoa(i)+"]", errs)
+		}
+	case reflect.Map:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return
+		}
+		// streams.* и прочие карты — тоже по порядку ключей, чтобы ошибки не прыгали
+		for _, k := range sortedKeys(obj) {
+			walkUnknown(obj[k], t.Elem(), joinPath(path, k), errs)
+		}
+	}
+}
+
+func sortedKeys(obj map[string]any) []string {
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func jsonFields(t reflect.Type) map[string]reflect.StructField {
+	out := make(map[string]reflect.StructField, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		out[name] = f
+	}
+	return out
+}
+
+func joinPath(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
@@ -0,0 +1,92 @@
+package history
+
+import (
+	"sort"
+	"time"
+)
+
+// Point is the status of one package at one commit.
+type Point struct {
+	Commit         string    `json:"commit"`
+	When           time.Time `json:"when"`
+	Status         string    `json:"status"`
+	Classification string    `json:"classification,omitempty"`
+	Passed         int       `json:"passed"`
+	Total          int       `json:"total"`
+}
+
+// Regression is a pass followed by a failure of the same package.
+type Regression struct {
+	LastPass Point `json:"last_pass"`
+	Broken   Point `json:"broken"`
+}
+
+// Timeline is the history of one package.
+type Timeline struct {
+	Package     string       `json:"package"`
+	Task        string       `json:"task,omitempty"`
+	Points      []Point      `json:"points"`
+	FirstPass   *Point       `json:"first_pass,omitempty"`
+	Regressions []Regression `json:"regressions,omitempty"`
+}
+
+// Latest returns the most recent point.
+func (t Timeline) Latest() Point {
+	return t.Points[len(t.Points)-1]
+}
+
+// Timelines groups runs (ordered as returned by Load) by package. Several
+// runs of the same commit in a row collapse into the last one, so a CI
+// re-run replaces rather than adds a point.
+func Timelines(runs []Run) []Timeline {
+	byPkg := map[string]*Timeline{}
+	for _, r := range runs {
+		for pkg, p := range r.Packages {
+			t, ok := byPkg[pkg]
+			if !ok {
+				t = &Timeline{Package: pkg}
+				byPkg[pkg] = t
+			}
+			if p.Task != "" {
+				t.Task = p.Task
+			}
+			pt := Point{
+				Commit:         r.Commit,
+				When:           r.When(),
+				Status:         p.Status,
+				Classification: p.Classification,
+				Passed:         p.Passed,
+				Total:          p.Total,
+			}
+			if n := len(t.Points); n > 0 && t.Points[n-1].Commit == r.Commit {
+				t.Points[n-1] = pt
+				continue
+			}
+			t.Points = append(t.Points, pt)
+		}
+	}
+
+	out := make([]Timeline, 0, len(byPkg))
+	for _, t := range byPkg {
+		var lastPass *Point
+		for i := range t.Points {
+			p := &t.Points[i]
+			switch p.Status {
+			case "pass":
+				if t.FirstPass == nil {
+					first := *p
+					t.FirstPass = &first
+				}
+				lastPass = p
+			case "fail":
+				if lastPass != nil {
+					t.Regressions = append(t.Regressions, Regression{LastPass: *lastPass, Broken: *p})
+					lastPass = nil
+				}
+			}
+		}
+		out = append(out, *t)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Package < out[j].Package })
+	return out
+}
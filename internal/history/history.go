@@ -0,0 +1,115 @@
+// Package history keeps an append-only log of test runs, one JSON line per
+// run, so task status can be followed across commits (.reports/history.jsonl
+// by default).
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// DefaultPath is where CI keeps the log, relative to the repo root.
+const DefaultPath = ".reports/history.jsonl"
+
+// Run is one testreport invocation.
+type Run struct {
+	Commit     string             `json:"commit"`
+	CommitTime time.Time          `json:"commit_time,omitzero"`
+	RecordedAt time.Time          `json:"recorded_at"`
+	Stream     string             `json:"stream,omitempty"`
+	Packages   map[string]Package `json:"packages"`
+}
+
+// Package is the part of a package result worth keeping over time.
+type Package struct {
+	Task           string   `json:"task,omitempty"`
+	Status         string   `json:"status"`
+	Classification string   `json:"classification,omitempty"`
+	Passed         int      `json:"passed"`
+	Total          int      `json:"total"`
+	Flaky          []string `json:"flaky,omitempty"`
+	Score          *float64 `json:"score,omitempty"`
+	Performance    string   `json:"performance,omitempty"` // benchmark limits: pass|fail
+}
+
+// When orders runs: commit time if known, otherwise when it was recorded.
+func (r Run) When() time.Time {
+	if !r.CommitTime.IsZero() {
+		return r.CommitTime
+	}
+	return r.RecordedAt
+}
+
+// Append adds run as one line at the end of the log, creating it if needed.
+// The line is written with a single write on an O_APPEND file, so
+// concurrent writers do not interleave.
+func Append(path string, run Run) error {
+	if run.Commit == "" {
+		return fmt.Errorf("history: run without commit")
+	}
+	b, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Load reads the log and returns runs ordered by When. A missing file is an
+// empty history. A malformed last line (a run killed mid-write) is skipped;
+// malformed lines elsewhere are errors.
+func Load(path string) ([]Run, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var (
+		runs    []Run
+		badLine int
+		badErr  error
+	)
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for n := 1; sc.Scan(); n++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		if badErr != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, badLine, badErr)
+		}
+		var r Run
+		if err := json.Unmarshal(sc.Bytes(), &r); err != nil {
+			badLine, badErr = n, err
+			continue
+		}
+		runs = append(runs, r)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(runs, func(i, j int) bool {
+		return runs[i].When().Before(runs[j].When())
+	})
+	return runs, nil
+}
@@ -0,0 +1,100 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAppendLoad(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), ".reports", "history.jsonl")
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 12, 0, 0, 0, time.UTC) }
+
+	// записываем не по порядку: Load сортирует по времени коммита
+	runs := []Run{
+		{Commit: "c2", CommitTime: day(2), Packages: map[string]Package{"m/tasks/task_00": {Task: "00", Status: "pass"}}},
+		{Commit: "c1", CommitTime: day(1), Packages: map[string]Package{"m/tasks/task_00": {Task: "00", Status: "fail"}}},
+	}
+	for _, r := range runs {
+		if err := Append(path, r); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+
+	got, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(got) != 2 || got[0].Commit != "c1" || got[1].Commit != "c2" {
+		t.Fatalf("Load = %+v", got)
+	}
+
+	if err := Append(path, Run{}); err == nil {
+		t.Error("expected error for run without commit")
+	}
+}
+
+func TestLoad_TruncatedLastLine(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	data := `{"commit":"c1","recorded_at":"2026-03-01T12:00:00Z","packages":{}}` + "\n" + `{"commit":"c2","reco`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runs, err := Load(path)
+	if err != nil || len(runs) != 1 {
+		t.Fatalf("Load = %v, %v; want the complete run only", runs, err)
+	}
+
+	data = `{"commit":"c2","reco` + "\n" + `{"commit":"c1","recorded_at":"2026-03-01T12:00:00Z","packages":{}}` + "\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("expected error for malformed line in the middle")
+	}
+
+	if runs, err := Load(filepath.Join(t.TempDir(), "missing.jsonl")); err != nil || runs != nil {
+		t.Errorf("Load(missing) = %v, %v", runs, err)
+	}
+}
+
+func TestTimelines(t *testing.T) {
+	t.Parallel()
+
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 12, 0, 0, 0, time.UTC) }
+	run := func(commit string, d int, status string) Run {
+		return Run{Commit: commit, CommitTime: day(d), Packages: map[string]Package{
+			"m/tasks/task_03": {Task: "03", Status: status},
+		}}
+	}
+	runs := []Run{
+		run("a", 1, "fail"),
+		run("b", 2, "pass"),
+		run("c", 3, "fail"),
+		run("c", 3, "fail"), // повторный запуск того же коммита
+		run("d", 4, "pass"),
+	}
+
+	tls := Timelines(runs)
+	if len(tls) != 1 {
+		t.Fatalf("Timelines = %+v", tls)
+	}
+	tl := tls[0]
+	if tl.Task != "03" || len(tl.Points) != 4 {
+		t.Errorf("timeline = %+v, want 4 points for task 03", tl)
+	}
+	if tl.FirstPass == nil || tl.FirstPass.Commit != "b" {
+		t.Errorf("first pass = %+v, want commit b", tl.FirstPass)
+	}
+	if len(tl.Regressions) != 1 || tl.Regressions[0].LastPass.Commit != "b" || tl.Regressions[0].Broken.Commit != "c" {
+		t.Errorf("regressions = %+v, want b -> c", tl.Regressions)
+	}
+	if tl.Latest().Commit != "d" {
+		t.Errorf("latest = %+v", tl.Latest())
+	}
+}